@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/hook"
+	"github.com/lawndlwd/golum/internal/policy"
+	"github.com/spf13/pflag"
+)
+
+// runHook implements `golum hook install|uninstall`.
+func runHook(args []string) {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintf(os.Stderr, "Usage:\n  golum hook install [flags]\n  golum hook uninstall [--project-path path]\n")
+		os.Exit(policy.ExitToolError)
+	}
+	action := args[0]
+
+	fs := pflag.NewFlagSet("hook "+action, pflag.ExitOnError)
+	repoPath := fs.String("project-path", ".", "Path to the repository to install hooks into")
+	rulesFile := fs.String("rules-file", "", "Rules file (.md) or directory used by the hooks")
+	targetBranch := fs.String("target-branch", "origin/main", "Branch new branches are reviewed from (merge base) by the pre-push hook")
+	failOn := fs.String("fail-on", "blocking", "Severity policy that blocks the commit or push")
+	binary := fs.String("golum-bin", defaultBinary(), "golum executable called by the hooks")
+	force := fs.Bool("force", false, "Overwrite existing hooks not written by golum")
+	_ = fs.Parse(args[1:])
+
+	if action == "uninstall" {
+		removed, err := hook.Uninstall(*repoPath)
+		if err != nil {
+			exitWithError(err)
+		}
+		if len(removed) == 0 {
+			fmt.Println("No golum hooks installed")
+		}
+		for _, path := range removed {
+			fmt.Printf("🗑️  Removed %s\n", path)
+		}
+		return
+	}
+
+	if *rulesFile == "" {
+		exitWithError(fmt.Errorf("--rules-file is required to install hooks"))
+	}
+	if _, err := policy.Parse(*failOn); err != nil {
+		exitWithError(err)
+	}
+	rulesPath, err := filepath.Abs(*rulesFile)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	written, err := hook.Install(hook.Options{
+		RepoPath:     *repoPath,
+		Binary:       *binary,
+		RulesPath:    rulesPath,
+		TargetBranch: *targetBranch,
+		FailOn:       *failOn,
+		Force:        *force,
+	})
+	for _, path := range written {
+		fmt.Printf("🪝 Installed %s\n", path)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	fmt.Printf("Set %s=1 to bypass the hooks. The AI token is read from AI_TOKEN or SCW_SECRET_KEY_AI_USER.\n", hook.BypassEnv)
+}
+
+// defaultBinary returns the running executable, or "golum" from PATH when
+// running through `go run` (whose binary lives in a temporary directory).
+func defaultBinary() string {
+	exe, err := os.Executable()
+	if err != nil || strings.HasPrefix(exe, os.TempDir()) {
+		return "golum"
+	}
+	return exe
+}
@@ -5,63 +5,94 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/lawndlwd/golum/internal/ai"
 	"github.com/lawndlwd/golum/internal/bestpractices"
+	"github.com/lawndlwd/golum/internal/diff"
 	"github.com/lawndlwd/golum/internal/filter"
 	"github.com/lawndlwd/golum/internal/git"
+	"github.com/lawndlwd/golum/internal/imports"
+	"github.com/lawndlwd/golum/internal/lang"
 	"github.com/lawndlwd/golum/internal/output"
 	"github.com/lawndlwd/golum/internal/parser"
+	"github.com/lawndlwd/golum/internal/policy"
 	"github.com/lawndlwd/golum/internal/review"
+	"github.com/lawndlwd/golum/internal/types"
 	"github.com/spf13/pflag"
 )
 
 type config struct {
-	AIToken       string
-	AIEndpoint    string
-	AIModel       string
-	Temperature   float64
-	Guidelines    string
-	RepoPath      string
-	TargetBranch  string
-	UseTreeSitter bool
-	Local         bool
+	AIToken          string
+	AIEndpoint       string
+	AIModel          string
+	Temperature      float64
+	Limits           ai.Limits
+	Guidelines       string
+	RepoPath         string
+	TargetBranch     string
+	UseTreeSitter    bool
+	ImportTokens     int
+	Local            bool
+	Staged           bool
+	Uncommitted      bool
+	Untracked        bool
+	Format           string
+	Patch            string
+	From             string
+	To               string
+	PerCommit        bool
+	IgnoreWhitespace bool
+	IgnoreMoved      bool
+	Include          []string
+	Exclude          []string
+	MaxFiles         int
+	ExplainFilter    bool
+	FailOn           policy.Policy
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "hook" {
+		runHook(os.Args[2:])
+		return
+	}
+
 	cfg, err := loadConfig()
 	if err != nil {
 		exitWithError(err)
 	}
 
-	ctx := context.Background()
-
-	best, err := bestpractices.LoadBestPractices(cfg.Guidelines)
+	// Built once: it decides which untracked files are listed and which
+	// changes are reviewed
+	fileFilter, err := newFilter(cfg)
 	if err != nil {
 		exitWithError(err)
 	}
 
-	aiClient := ai.NewClient(cfg.AIToken, cfg.AIEndpoint, cfg.AIModel, cfg.Temperature)
+	ctx := context.Background()
 
-	diffs, err := git.LocalChanges(git.LocalOptions{
-		RepoPath:        cfg.RepoPath,
-		BaseRef:         cfg.TargetBranch,
-		TargetBranch:    cfg.TargetBranch,
-		IncludeUnstaged: true,
-		Local:           cfg.Local,
-	})
+	rules, err := bestpractices.Load(cfg.Guidelines)
 	if err != nil {
 		exitWithError(err)
 	}
 
-	fmt.Printf("📊 Found %d changed files\n", len(diffs))
-
-	// APPLY THE FILTER!
-	diffs = filter.FilterEligible(diffs, 0) // 0 = no limit
+	astRules, err := parser.CompileRules(rules.ASTRules())
+	if err != nil {
+		exitWithError(err)
+	}
 
-	fmt.Printf("🔍 Filtered to %d TypeScript/JavaScript files for review\n", len(diffs))
+	// Without a token only the AST rules are checked
+	var aiClient *ai.Client
+	if cfg.AIToken != "" {
+		aiClient = ai.NewClient(cfg.AIToken, cfg.AIEndpoint, cfg.AIModel, cfg.Temperature, cfg.Limits)
+	} else if astRules.Len() > 0 {
+		fmt.Printf("ℹ️  No AI token: only the %d AST rule(s) are checked\n", astRules.Len())
+	} else {
+		exitWithError(errors.New("ai token is required unless the rules define AST rules"))
+	}
 
 	// Initialize Tree-sitter parser
 	p := parser.NewParser()
@@ -69,15 +100,248 @@ func main() {
 		fmt.Printf("⚠️  Tree-sitter initialization failed: %v. Falling back to simple diff.\n", err)
 		cfg.UseTreeSitter = false
 	}
-	defer p.Close()
+	// os.Exit skips deferred calls
+	release := func() {
+		astRules.Close()
+		p.Close()
+	}
+
+	var result review.Result
+	if cfg.PerCommit {
+		commits, commitResult, err := reviewCommits(ctx, cfg, aiClient, p, rules, astRules, fileFilter)
+		if err != nil {
+			release()
+			exitWithError(err)
+		}
+		result = commitResult
+		if cfg.Format == "compact" {
+			output.PrintCompact(result.Comments)
+		} else {
+			output.PrintSummary(result.Summary)
+			output.PrintByCommit(commits, result.Comments)
+		}
+	} else {
+		diffs, err := loadDiffs(cfg, fileFilter)
+		if err != nil {
+			release()
+			exitWithError(err)
+		}
+		result = reviewDiffs(ctx, cfg, aiClient, p, rules, astRules, fileFilter, diffs)
+		if cfg.Format == "compact" {
+			output.PrintCompact(result.Comments)
+		} else {
+			output.PrintSummary(result.Summary)
+			output.PrintLocal(result.Comments)
+		}
+	}
+
+	code := exitCode(cfg.FailOn, result)
+	release()
+	os.Exit(code)
+}
+
+// reviewDiffs filters the changes, checks their syntax and the AST rules
+// locally, summarizes the declarations they touch and, with a client,
+// reviews the files with the model.
+func reviewDiffs(ctx context.Context, cfg config, client *ai.Client, p *parser.Parser, rules *bestpractices.Rules, astRules *parser.RuleSet, fileFilter *filter.Filter, diffs []types.FileDiff) review.Result {
+	fmt.Printf("📊 Found %d changed files\n", len(diffs))
+
+	if cfg.IgnoreWhitespace {
+		diffs = git.IgnoreWhitespace(diffs)
+	}
+	if cfg.IgnoreMoved {
+		git.MarkMoved(diffs)
+	}
+
+	deleted := fileFilter.Deleted(diffs)
+	diffs, decisions := fileFilter.Apply(diffs)
+	if cfg.ExplainFilter {
+		output.PrintFilterDecisions(decisions)
+	}
+
+	languages := diffLanguages(diffs)
+	names := make([]string, len(languages))
+	for i, l := range languages {
+		names[i] = l.Name
+	}
+	fmt.Printf("🔍 Filtered to %d file(s) for review (%s)\n", len(diffs), strings.Join(names, ", "))
+
+	// The resolver finds the importers of removed exports even when no
+	// import context is added to the prompt
+	var resolver *imports.Resolver
+	if cfg.UseTreeSitter && cfg.RepoPath != "" {
+		resolver = imports.NewResolver(cfg.RepoPath, p, cfg.ImportTokens)
+	}
+
+	var result review.Result
+	if cfg.UseTreeSitter {
+		result.Comments = review.Check(p, astRules, diffs, cfg.RepoPath)
+		fmt.Printf("🧩 Syntax and AST rule checks found %d issue(s)\n", len(result.Comments))
+
+		summary, removals := review.Summarize(p, resolver, diffs, deleted, cfg.RepoPath)
+		result.Summary = summary
+		result.Comments = append(result.Comments, removals...)
+		fmt.Printf("🧬 Summarized the declarations changed in %d file(s); %d removal(s) are still imported\n", len(summary), len(removals))
+	} else if astRules.Len() > 0 {
+		fmt.Println("⚠️  Tree-sitter is disabled; skipping AST rules")
+	}
+	if client == nil {
+		result.Comments = review.MergeComments(result.Comments)
+		return result
+	}
+	result.Add(review.Review(ctx, client, p, resolver, rules.For(languages), diffs, cfg.RepoPath, cfg.UseTreeSitter))
+	result.Comments = review.MergeComments(result.Comments)
+	return result
+}
+
+// diffLanguages returns the languages of the files to review, in order of
+// first appearance.
+func diffLanguages(diffs []types.FileDiff) []*lang.Language {
+	seen := make(map[*lang.Language]bool)
+	var languages []*lang.Language
+	for _, d := range diffs {
+		if l := lang.ForPath(d.NewPath); l != nil && !seen[l] {
+			seen[l] = true
+			languages = append(languages, l)
+		}
+	}
+	return languages
+}
+
+// reviewCommits reviews every commit of the range on its own, with its
+// message as context, and tags the comments with the commit SHA.
+func reviewCommits(ctx context.Context, cfg config, client *ai.Client, p *parser.Parser, rules *bestpractices.Rules, astRules *parser.RuleSet, fileFilter *filter.Filter) ([]types.Commit, review.Result, error) {
+	to := cfg.To
+	if to == "" {
+		to = "HEAD"
+	}
+	from := cfg.From
+	if from == "" {
+		// Default to the commits of the current branch
+		base, err := git.MergeBase(cfg.RepoPath, cfg.TargetBranch, to)
+		if err != nil {
+			return nil, review.Result{}, err
+		}
+		from = base
+	}
+
+	// An empty range is almost always a mistake, e.g. reviewing the target
+	// branch itself, and would otherwise pass silently
+	fromSHA, err := git.ResolveCommit(cfg.RepoPath, from)
+	if err != nil {
+		return nil, review.Result{}, err
+	}
+	toSHA, err := git.ResolveCommit(cfg.RepoPath, to)
+	if err != nil {
+		return nil, review.Result{}, err
+	}
+	if fromSHA == toSHA {
+		if cfg.From == "" {
+			return nil, review.Result{}, fmt.Errorf("%s has no commits that are not on %s; pass --from or --target-branch", to, cfg.TargetBranch)
+		}
+		return nil, review.Result{}, fmt.Errorf("--from %s and --to %s are the same commit", from, to)
+	}
 
-	comments := review.Review(ctx, aiClient, p, best, diffs, cfg.RepoPath, cfg.TargetBranch, cfg.UseTreeSitter)
+	commits, err := git.Commits(cfg.RepoPath, from, to)
+	if err != nil {
+		return nil, review.Result{}, err
+	}
+	if len(commits) == 0 && cfg.From == "" {
+		return nil, review.Result{}, fmt.Errorf("no commits to review between %s and %s", cfg.TargetBranch, to)
+	}
+	fmt.Printf("🔖 Reviewing %d commit(s) one at a time\n\n", len(commits))
+
+	var result review.Result
+	for _, commit := range commits {
+		fmt.Printf("🔖 %s %s\n", commit.ShortSHA(), commit.Subject)
+		diffs, err := git.CommitChanges(cfg.RepoPath, commit)
+		if err != nil {
+			return nil, review.Result{}, err
+		}
 
-	output.PrintLocal(comments)
+		commitResult := reviewDiffs(ctx, cfg, client, p, rules, astRules, fileFilter, diffs)
+		for i := range commitResult.Comments {
+			commitResult.Comments[i].Commit = commit.SHA
+		}
+		result.Add(commitResult)
+	}
+	return commits, result, nil
+}
+
+// loadDiffs reads the changes to review, either from a patch file or from git.
+func loadDiffs(cfg config, fileFilter *filter.Filter) ([]types.FileDiff, error) {
+	if cfg.Patch != "" {
+		text, err := readPatch(cfg.Patch)
+		if err != nil {
+			return nil, err
+		}
+		// The patch may not match the checked-out tree, so only its hunks are
+		// trusted for the new side. HEAD is the base only for files whose
+		// removed and context lines it holds; the patch may have been made
+		// against another commit.
+		diffs := diff.ParseUnified(text)
+		base := types.Revision("HEAD")
+		for i := range diffs {
+			diffs[i].NewSource = types.HunksOnly()
+			diffs[i].OldSource = types.HunksOnly()
+			if cfg.RepoPath != "" && diff.MatchesBase(cfg.RepoPath, base, diffs[i]) {
+				diffs[i].OldSource = base
+			}
+		}
+		return diffs, nil
+	}
+
+	return git.LocalChanges(git.LocalOptions{
+		RepoPath:         cfg.RepoPath,
+		BaseRef:          cfg.TargetBranch,
+		TargetBranch:     cfg.TargetBranch,
+		IncludeUnstaged:  cfg.Uncommitted,
+		Local:            cfg.Local,
+		Staged:           cfg.Staged,
+		IncludeUntracked: cfg.Untracked,
+		From:             cfg.From,
+		To:               cfg.To,
+		KeepUntracked:    fileFilter.Wants,
+	})
+}
+
+func newFilter(cfg config) (*filter.Filter, error) {
+	return filter.New(filter.Options{
+		RepoPath: cfg.RepoPath,
+		Include:  cfg.Include,
+		Exclude:  cfg.Exclude,
+		MaxFiles: cfg.MaxFiles,
+	})
+}
+
+func readPatch(path string) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read patch %s: %w", path, err)
+	}
+	return string(content), nil
+}
 
-	if output.CountSeverity(comments, "critical") > 0 {
-		os.Exit(1)
+func exitCode(failOn policy.Policy, result review.Result) int {
+	if violations := failOn.Violations(result.Comments); len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Printf("🚫 --fail-on exceeded: %s\n", v)
+		}
+		return policy.ExitFindings
 	}
+	if result.Partial() {
+		fmt.Printf("⚠️  %d of %d batch(es) could not be reviewed\n", result.FailedBatches, result.Batches)
+		return policy.ExitPartialReview
+	}
+	return policy.ExitOK
 }
 
 func loadConfig() (config, error) {
@@ -90,28 +354,69 @@ func loadConfig() (config, error) {
 		return fallback
 	}
 
+	// "review" is the default command and may be omitted
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "review" {
+		args = args[1:]
+	}
+
 	fs := pflag.NewFlagSet("review", pflag.ExitOnError)
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "AI code review CLI with Tree-sitter\n\nExamples:\n  golum --project-path ../project-name --target-branch origin/main --ai-token $AI_TOKEN --rules-file ./rules/rules.md\n  golum --project-path ../project-name --target-branch origin/main --ai-token $AI_TOKEN --rules-file /path/to/rules\n\nFlags:\n")
+		fmt.Fprintf(os.Stderr, "AI code review CLI with Tree-sitter\n\nUsage:\n  golum [review] [flags]\n  golum hook install|uninstall [flags]\n\nExamples:\n  golum --project-path ../project-name --target-branch origin/main --ai-token $AI_TOKEN --rules-file ./rules/rules.md\n  golum --project-path ../project-name --target-branch origin/main --ai-token $AI_TOKEN --rules-file /path/to/rules\n  git format-patch -1 --stdout | golum review --patch - --ai-token $AI_TOKEN --rules-file ./rules/rules.md\n\nFlags:\n")
 		fs.PrintDefaults()
 	}
 	aiToken := fs.String("ai-token", env("", "SCW_SECRET_KEY_AI_USER", "AI_TOKEN"), "Scaleway AI token")
 	aiEndpoint := fs.String("ai-endpoint", env("https://api.scaleway.ai/3e211a1d-e19d-4e63-b47f-c88d70377aac/v1", "SCALEWAY_AI_ENDPOINT"), "Scaleway AI endpoint")
 	aiModel := fs.String("ai-model", env("qwen3-235b-a22b-instruct-2507", "SCALEWAY_AI_MODEL"), "AI model name")
 	temp := fs.Float64("temperature", envFloat("REVIEW_TEMPERATURE", 0.0), "Sampling temperature for the AI model (use 0 for consistent results)")
+	contextWindow := fs.Int("context-window", envInt("AI_CONTEXT_WINDOW", 32768), "Context window of the AI model in tokens; batches are sized to fit it")
+	maxOutputTokens := fs.Int("max-output-tokens", envInt("AI_MAX_TOKENS", 8000), "Tokens reserved for the AI answer (sent as max_tokens)")
 	rulesFile := fs.String("rules-file", "", "Path to rules file (.md) or directory containing .md files (overrides --rules-dir)")
 	rulesDir := fs.String("rules-dir", defaultRulesDir(), "Rules directory (ignored if --rules-file is set)")
 	useTreeSitter := fs.Bool("tree-sitter", envBool("USE_TREE_SITTER", true), "Use Tree-sitter for enhanced context")
+	importTokens := fs.Int("import-context-tokens", envInt("IMPORT_CONTEXT_TOKENS", 1500), "Token budget per file for the signatures of imported modules used by the changes (0 = off)")
 
 	repoPath := fs.String("project-path", ".", "Path to repository when running locally")
 	targetBranch := fs.String("target-branch", env("HEAD", "TARGET_BRANCH"), "Base branch for local diffs")
 	local := fs.Bool("local", envBool("LOCAL", false), "Compare local changes (staged + unstaged) to origin/target-branch")
+	uncommitted := fs.Bool("include-uncommitted", envBool("INCLUDE_UNCOMMITTED", true), "Review the working tree (staged + unstaged changes) on top of the branch commits")
+	staged := fs.Bool("staged", envBool("STAGED", false), "Review only staged changes (the index), e.g. from a pre-commit hook")
+	untracked := fs.Bool("untracked", envBool("UNTRACKED", true), "With --local, also review untracked files that are not ignored")
+	patch := fs.String("patch", "", "Review a unified diff or patch file instead of git changes (use - for stdin)")
+	from := fs.String("from", "", "Review the commit range --from..--to instead of the branch diff")
+	to := fs.String("to", "", "End of the commit range (defaults to HEAD)")
+	perCommit := fs.Bool("per-commit", envBool("PER_COMMIT", false), "Review each commit of the range on its own, with its message as context")
+	ignoreWhitespace := fs.Bool("ignore-whitespace", envBool("IGNORE_WHITESPACE", false), "Skip hunks that only change whitespace")
+	ignoreMoved := fs.Bool("ignore-moved", envBool("IGNORE_MOVED", false), "Detect code moved between or within files and do not review it")
+	include := fs.StringSlice("include", envList("GOLUM_INCLUDE"), "Only review files matching these gitignore-style globs (repeatable or comma separated)")
+	exclude := fs.StringSlice("exclude", envList("GOLUM_EXCLUDE"), "Skip files matching these gitignore-style globs, on top of .golumignore (repeatable or comma separated)")
+	maxFiles := fs.Int("max-files", envInt("MAX_FILES", 0), "Review at most this many files (0 = no limit)")
+	explainFilter := fs.Bool("explain-filter", false, "Print why each changed file was kept or dropped")
+	format := fs.String("format", env("pretty", "OUTPUT_FORMAT"), "Output format: pretty or compact")
+	failOn := fs.String("fail-on", env("blocking", "FAIL_ON"), "Severity policy for a failing exit code: none, suggestion, issue, blocking, or thresholds like issue:3,blocking:1")
 
 	fs.AddGoFlagSet(flag.CommandLine)
-	_ = fs.Parse(os.Args[1:])
+	_ = fs.Parse(args)
 
-	if *aiToken == "" {
-		return config{}, errors.New("ai token is required")
+	if *format != "pretty" && *format != "compact" {
+		return config{}, fmt.Errorf("invalid --format %q (expected pretty or compact)", *format)
+	}
+	if *staged && (*local || *patch != "") {
+		return config{}, errors.New("--staged cannot be combined with --local or --patch")
+	}
+	if (*from != "" || *to != "" || *perCommit) && (*staged || *local || *patch != "") {
+		return config{}, errors.New("--from, --to and --per-commit cannot be combined with --staged, --local or --patch")
+	}
+	if *to != "" && *from == "" && !*perCommit {
+		return config{}, errors.New("--to requires --from")
+	}
+	if *maxOutputTokens <= 0 || *contextWindow <= *maxOutputTokens {
+		return config{}, fmt.Errorf("--context-window (%d) must be larger than --max-output-tokens (%d)", *contextWindow, *maxOutputTokens)
+	}
+
+	failPolicy, err := policy.Parse(*failOn)
+	if err != nil {
+		return config{}, err
 	}
 
 	// Use rules-file if provided, otherwise fall back to rules-dir
@@ -126,15 +431,38 @@ func loadConfig() (config, error) {
 	}
 
 	cfg := config{
-		AIToken:       *aiToken,
-		AIEndpoint:    *aiEndpoint,
-		AIModel:       *aiModel,
-		Temperature:   *temp,
-		Guidelines:    rulesPath,
-		RepoPath:      *repoPath,
-		TargetBranch:  *targetBranch,
-		UseTreeSitter: *useTreeSitter,
-		Local:         *local,
+		AIToken:          *aiToken,
+		AIEndpoint:       *aiEndpoint,
+		AIModel:          *aiModel,
+		Temperature:      *temp,
+		Limits:           ai.Limits{ContextWindow: *contextWindow, MaxOutputTokens: *maxOutputTokens},
+		Guidelines:       rulesPath,
+		RepoPath:         *repoPath,
+		TargetBranch:     *targetBranch,
+		UseTreeSitter:    *useTreeSitter,
+		ImportTokens:     *importTokens,
+		Local:            *local,
+		Staged:           *staged,
+		Uncommitted:      *uncommitted,
+		Untracked:        *untracked,
+		Format:           *format,
+		Patch:            *patch,
+		From:             *from,
+		To:               *to,
+		PerCommit:        *perCommit,
+		IgnoreWhitespace: *ignoreWhitespace,
+		IgnoreMoved:      *ignoreMoved,
+		Include:          *include,
+		Exclude:          *exclude,
+		MaxFiles:         *maxFiles,
+		ExplainFilter:    *explainFilter,
+		FailOn:           failPolicy,
+	}
+
+	// Without a repository the review path is cleared so a patch gets its
+	// context from its hunks instead
+	if cfg.Patch != "" && !git.IsRepository(cfg.RepoPath) {
+		cfg.RepoPath = ""
 	}
 
 	return cfg, nil
@@ -149,6 +477,25 @@ func envFloat(key string, fallback float64) float64 {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	if val := os.Getenv(key); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func envList(key string) []string {
+	var result []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func envBool(key string, fallback bool) bool {
 	if val := os.Getenv(key); val != "" {
 		if parsed, err := strconv.ParseBool(val); err == nil {
@@ -167,5 +514,5 @@ func defaultRulesDir() string {
 
 func exitWithError(err error) {
 	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-	os.Exit(1)
+	os.Exit(policy.ExitToolError)
 }
@@ -12,39 +12,77 @@ import (
 	"github.com/lawndlwd/golum/internal/types"
 )
 
+const systemPrompt = "You are a deterministic senior software engineer performing a code review. You must produce IDENTICAL results for identical inputs."
+
+// Limits describes the token budget of the model.
+type Limits struct {
+	ContextWindow   int // Total tokens the model accepts, prompt and answer included
+	MaxOutputTokens int // Tokens reserved for the answer, sent as max_tokens
+}
+
 type Client struct {
 	apiKey      string
 	baseURL     string
 	model       string
 	temperature float64
+	limits      Limits
 	httpClient  *http.Client
 }
 
-func NewClient(apiKey, baseURL, model string, temperature float64) *Client {
+func NewClient(apiKey, baseURL, model string, temperature float64, limits Limits) *Client {
 	return &Client{
 		apiKey:      strings.TrimSpace(apiKey),
 		baseURL:     strings.TrimRight(baseURL, "/"),
 		model:       model,
 		temperature: temperature,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		limits:      limits,
+		httpClient:  &http.Client{Timeout: 60 * time.Second},
 	}
 }
 
+// InputBudget returns the number of prompt tokens that leave room for the
+// reserved output in the context window.
+func (c *Client) InputBudget() int {
+	return c.limits.ContextWindow - c.limits.MaxOutputTokens
+}
+
+// PromptTokens estimates the size of the request ReviewBatch would send.
+func (c *Client) PromptTokens(bestPractices string, diffs []types.FileDiff, contexts []*types.CodeContext) int {
+	return EstimateTokens(systemPrompt) + EstimateTokens(BuildBatchPrompt(bestPractices, diffs, contexts))
+}
+
+// PromptOverhead estimates the size of the request for diffs without their
+// file sections: the system prompt, rules, commit and instructions. Adding
+// the FileTokens of every file estimates the whole request.
+func (c *Client) PromptOverhead(bestPractices string, diffs []types.FileDiff) int {
+	var b strings.Builder
+	writePromptHeader(&b, bestPractices, diffs)
+	writeInstructions(&b)
+	return EstimateTokens(systemPrompt) + EstimateTokens(b.String())
+}
+
+// FileTokens estimates the size of the section of a file in a request.
+func (c *Client) FileTokens(diff types.FileDiff, context *types.CodeContext) int {
+	var b strings.Builder
+	writeFileSection(&b, 1, diff, context)
+	return EstimateTokens(b.String())
+}
+
 func (c *Client) ReviewBatch(ctx context.Context, bestPractices string, diffs []types.FileDiff, contexts []*types.CodeContext) (types.AIReviewResponse, error) {
 	payload := map[string]any{
 		"model": c.model,
 		"messages": []map[string]string{
 			{
 				"role":    "system",
-				"content": "You are a deterministic senior software engineer performing a code review. You must produce IDENTICAL results for identical inputs.",
+				"content": systemPrompt,
 			},
 			{
 				"role":    "user",
 				"content": BuildBatchPrompt(bestPractices, diffs, contexts),
 			},
 		},
-		"temperature":      0.1,  // Force deterministic output
-		"max_tokens":       8000, // Increased for batch processing
+		"temperature":      0.1, // Force deterministic output
+		"max_tokens":       c.limits.MaxOutputTokens,
 		"presence_penalty": 0.0,
 		"top_p":            0.5,
 		"seed":             1234,
@@ -85,7 +123,7 @@ func (c *Client) ReviewBatch(ctx context.Context, bestPractices string, diffs []
 		return types.AIReviewResponse{}, fmt.Errorf("empty AI response")
 	}
 
-	return ParseBatchResponse(content), nil
+	return ParseBatchResponse(content)
 }
 
 type completionsResponse struct {
@@ -5,15 +5,40 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/lawndlwd/golum/internal/types"
 )
 
+// maxPromptSymbols caps the changed declarations listed for a file, e.g. for
+// a new file declaring many.
+const maxPromptSymbols = 20
+
 var fencedJSON = regexp.MustCompile("```(?:json)?\\s*([\\s\\S]*?)\\s*```")
 
 func BuildBatchPrompt(bestPractices string, files []types.FileDiff, contexts []*types.CodeContext) string {
 	var b strings.Builder
+	writePromptHeader(&b, bestPractices, files)
+
+	// Sort files by path to ensure consistent ordering
+	sortedIndices := make([]int, len(files))
+	for i := range files {
+		sortedIndices[i] = i
+	}
+	sort.Slice(sortedIndices, func(i, j int) bool {
+		return files[sortedIndices[i]].NewPath < files[sortedIndices[j]].NewPath
+	})
+
+	for idx, i := range sortedIndices {
+		writeFileSection(&b, idx+1, files[i], contexts[i])
+	}
+
+	writeInstructions(&b)
+	return b.String()
+}
+
+func writePromptHeader(b *strings.Builder, bestPractices string, files []types.FileDiff) {
 	b.WriteString("# Code Review Task - Multiple Files\n\n")
 	b.WriteString("You are a deterministic senior software engineer performing a code review. You must produce IDENTICAL results for identical inputs.\n")
 	b.WriteString("Review ALL files ONLY against the Scaleway best practices provided below.\n")
@@ -22,54 +47,110 @@ func BuildBatchPrompt(bestPractices string, files []types.FileDiff, contexts []*
 	b.WriteString("## Scaleway Best Practices\n")
 	b.WriteString(bestPractices)
 
+	if commit := batchCommit(files); commit != nil {
+		b.WriteString("\n## Commit Under Review\n\n")
+		b.WriteString(fmt.Sprintf("Commit %s. Its message describes the intent of the change; use it as context only, the rules still decide what is a violation.\n\n", commit.ShortSHA()))
+		b.WriteString("```\n")
+		b.WriteString(commit.Message)
+		b.WriteString("\n```\n")
+	}
+
 	b.WriteString("\n## Files Being Reviewed\n\n")
+}
 
-	// Sort files by path to ensure consistent ordering
-	sortedIndices := make([]int, len(files))
-	for i := range files {
-		sortedIndices[i] = i
+// writeFileSection writes the diff and context of the numberth file of a
+// request.
+func writeFileSection(b *strings.Builder, number int, file types.FileDiff, context *types.CodeContext) {
+	b.WriteString(fmt.Sprintf("### File %d: %s\n", number, file.NewPath))
+	b.WriteString(fmt.Sprintf("**Language:** %s | **Changes:** +%d -%d\n", file.Language, file.Additions, file.Deletions))
+	switch file.Status {
+	case types.StatusRenamed, types.StatusCopied:
+		b.WriteString(fmt.Sprintf("**%s from:** %s (similarity %d%%) - only the delta is shown, review only the changed lines\n", statusTitle(file.Status), file.OldPath, file.Similarity))
+	case types.StatusAdded:
+		b.WriteString("**New file**\n")
 	}
-	sort.Slice(sortedIndices, func(i, j int) bool {
-		return files[sortedIndices[i]].NewPath < files[sortedIndices[j]].NewPath
-	})
+	if file.Parts > 0 {
+		b.WriteString(fmt.Sprintf("**Part %d of %d** - this file is too large to review at once; only these hunks are shown here, the others are reviewed separately\n", file.Part, file.Parts))
+	}
+	if context != nil && len(context.SyntaxErrors) > 0 {
+		errs := make([]string, len(context.SyntaxErrors))
+		for j, e := range context.SyntaxErrors {
+			errs[j] = e.String()
+		}
+		b.WriteString(fmt.Sprintf("**Does not parse:** the changed lines have syntax errors (%s). They are already reported; do not comment on the style of the broken code\n", strings.Join(errs, "; ")))
+	}
+	if len(file.Moves) > 0 {
+		b.WriteString("**Moved code (already reviewed, DO NOT comment on these lines):**")
+		for _, m := range file.Moves {
+			b.WriteString(fmt.Sprintf(" lines %d-%d moved from %s;", m.Start, m.End, m.From))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
-	for idx, i := range sortedIndices {
-		file := files[i]
-		b.WriteString(fmt.Sprintf("### File %d: %s\n", idx+1, file.NewPath))
-		b.WriteString(fmt.Sprintf("**Language:** %s | **Changes:** +%d -%d\n\n", file.Language, file.Additions, file.Deletions))
+	if len(file.Symbols) > 0 {
+		b.WriteString("**Declarations changed (compared with the base version):**\n")
+		writeSymbols(b, file.Symbols)
+		b.WriteString("\n")
+	}
 
-		b.WriteString("```diff\n")
-		b.WriteString(file.Diff)
-		b.WriteString("\n```\n\n")
-
-		if contexts[i] != nil && len(contexts[i].Surrounding) > 0 {
-			b.WriteString("**Enhanced Context:**\n")
-			// Sort line numbers for consistent ordering
-			sortedLines := make([]int, len(contexts[i].ChangedLines))
-			copy(sortedLines, contexts[i].ChangedLines)
-			sort.Ints(sortedLines)
-
-			for _, lineNum := range sortedLines {
-				if surrounding, ok := contexts[i].Surrounding[lineNum]; ok && surrounding != "" {
-					b.WriteString(fmt.Sprintf("\nLine %d context:\n%s\n", lineNum, surrounding))
-				}
+	b.WriteString("```diff\n")
+	writeNumberedDiff(b, file)
+	b.WriteString("```\n\n")
+
+	if context != nil && len(context.Regions) > 0 {
+		b.WriteString("**Enhanced Context:**\n")
+		writeRegions(b, context.Regions)
+	}
+
+	if context != nil && len(context.Definitions) > 0 {
+		b.WriteString("**Definitions used by the changed lines (same file):**\n")
+		for _, def := range context.Definitions {
+			b.WriteString(fmt.Sprintf("\n%s `%s` (lines %d-%d", def.Kind, def.Name, def.Start, def.End))
+			if def.Partial {
+				b.WriteString(", truncated")
+			}
+			b.WriteString("):\n")
+			b.WriteString(def.Text)
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if context != nil && len(context.Imported) > 0 {
+		b.WriteString("**Imported definitions used by the changed lines:**\n")
+		for _, def := range context.Imported {
+			b.WriteString(fmt.Sprintf("\n%s `%s` from %s (lines %d-%d", def.Kind, def.Name, def.Path, def.Start, def.End))
+			if def.Partial {
+				b.WriteString(", signature only")
 			}
+			b.WriteString("):\n")
+			b.WriteString(def.Text)
 			b.WriteString("\n")
 		}
+		b.WriteString("\n")
+	}
 
-		b.WriteString(strings.Repeat("-", 80))
-		b.WriteString("\n\n")
+	if context != nil && len(context.Removed) > 0 {
+		b.WriteString("**Removed code (base version, for reference only):**\n")
+		writeRegions(b, context.Removed)
 	}
 
+	b.WriteString(strings.Repeat("-", 80))
+	b.WriteString("\n\n")
+}
+
+func writeInstructions(b *strings.Builder) {
 	b.WriteString("\n## CRITICAL Instructions - Follow Exactly\n\n")
 	b.WriteString("1. Review ALL files in the order presented above\n")
-	b.WriteString("2. For each file, analyze ONLY the changed lines (lines starting with + in the diff)\n")
+	b.WriteString("2. For each file, analyze ONLY the changed lines (lines starting with + in the diff). The number before | is the line number in the new file\n")
 	b.WriteString("3. Check if code violates ANY specific rule from the best practices\n")
 	b.WriteString("4. DO NOT report issues based on general coding style or personal preference\n")
 	b.WriteString("5. BE CONSISTENT: The same code violation must ALWAYS produce the same comment\n")
 	b.WriteString("6. For each violation found, you MUST provide:\n")
 	b.WriteString("   - **filePath**: The exact file path as shown above (e.g., \"src/components/Button.tsx\")\n")
-	b.WriteString("   - **line**: The exact line number from the diff where the violation occurs\n")
+	b.WriteString("   - **line**: The exact line number shown before | on the + line where the violation occurs\n")
+	b.WriteString("   - **code**: The exact content of that line, copied from the diff without the + marker and line number\n")
 	b.WriteString("   - **severity**: One of: \"suggestion(blocking)\", \"suggestion(non-blocking)\", \"issue\"\n")
 	b.WriteString("   - **comment**: Write a humanized, conversational comment starting with the severity prefix. Examples:\n")
 	b.WriteString("     * \"suggestion(blocking): Can you make a unit test here?\"\n")
@@ -79,7 +160,7 @@ func BuildBatchPrompt(bestPractices string, files []types.FileDiff, contexts []*
 
 	b.WriteString("## Response Format - MANDATORY\n\n")
 	b.WriteString("You MUST respond with ONLY valid JSON in this EXACT format (no additional text before or after):\n\n")
-	b.WriteString("```json\n{\n  \"comments\": [\n    {\n      \"filePath\": \"exact/file/path.ts\",\n      \"line\": 42,\n      \"severity\": \"issue\",\n      \"comment\": \"issue: Wait for production availability before deploying this feature\"\n    },\n    {\n      \"filePath\": \"exact/file/path.ts\",\n      \"line\": 50,\n      \"severity\": \"suggestion(blocking)\",\n      \"comment\": \"suggestion(blocking): Can you make a unit test here?\"\n    }\n  ],\n  \"summary\": \"Found N violations across M files. Main issues: ...\"\n}\n```\n\n")
+	b.WriteString("```json\n{\n  \"comments\": [\n    {\n      \"filePath\": \"exact/file/path.ts\",\n      \"line\": 42,\n      \"code\": \"const data = await fetchUser(id);\",\n      \"severity\": \"issue\",\n      \"comment\": \"issue: Wait for production availability before deploying this feature\"\n    },\n    {\n      \"filePath\": \"exact/file/path.ts\",\n      \"line\": 50,\n      \"code\": \"export default function Button() {\",\n      \"severity\": \"suggestion(blocking)\",\n      \"comment\": \"suggestion(blocking): Can you make a unit test here?\"\n    }\n  ],\n  \"summary\": \"Found N violations across M files. Main issues: ...\"\n}\n```\n\n")
 
 	b.WriteString("IMPORTANT:\n")
 	b.WriteString("- If NO violations found, return: {\"comments\": [], \"summary\": \"No violations found\"}\n")
@@ -88,23 +169,125 @@ func BuildBatchPrompt(bestPractices string, files []types.FileDiff, contexts []*
 	b.WriteString("- Always phrase comments the same way for identical violations\n")
 	b.WriteString("- Write comments in a natural, humanized way - be conversational and friendly\n")
 	b.WriteString("- The comment should start with the severity prefix (e.g., \"suggestion(blocking):\", \"issue:\")\n")
+}
 
-	return b.String()
+// writeSymbols lists the declarations a file's change touched, the full
+// signatures of signature changes included.
+func writeSymbols(b *strings.Builder, symbols []types.SymbolChange) {
+	for i, s := range symbols {
+		if i == maxPromptSymbols {
+			b.WriteString(fmt.Sprintf("- ... and %d more\n", len(symbols)-i))
+			break
+		}
+		b.WriteString("- " + s.String())
+		if s.Change == types.SymbolSignature {
+			b.WriteString(fmt.Sprintf(": `%s` -> `%s`", s.Before, s.After))
+		}
+		if len(s.ImportedBy) > 0 {
+			b.WriteString(fmt.Sprintf(" (still imported by %s)", strings.Join(s.ImportedBy, ", ")))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// writeNumberedDiff renders the hunks of a file with the new-side line number
+// of every line, so the model does not have to count lines itself.
+func writeNumberedDiff(b *strings.Builder, file types.FileDiff) {
+	if len(file.Hunks) == 0 {
+		b.WriteString(file.Diff)
+		b.WriteString("\n")
+		return
+	}
+	for _, hunk := range file.Hunks {
+		b.WriteString(hunk.Header())
+		b.WriteString("\n")
+		for _, line := range hunk.Lines {
+			num := ""
+			if line.Kind != types.LineRemoved {
+				num = strconv.Itoa(line.NewLine)
+			}
+			b.WriteString(fmt.Sprintf("%s%5s | %s\n", line.Kind.Prefix(), num, line.Content))
+		}
+	}
 }
 
-func ParseBatchResponse(raw string) types.AIReviewResponse {
+// writeRegions renders context regions, each titled with its line range and
+// the scopes it covers.
+func writeRegions(b *strings.Builder, regions []types.ContextRegion) {
+	for _, region := range regions {
+		b.WriteString(fmt.Sprintf("\nLines %d-%d", region.Start, region.End))
+		if len(region.Scopes) > 0 {
+			b.WriteString(" (" + strings.Join(region.Scopes, ", ") + ")")
+		}
+		b.WriteString(":\n")
+		b.WriteString(region.Text)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// batchCommit returns the commit shared by every file of the batch, if any.
+func batchCommit(files []types.FileDiff) *types.Commit {
+	if len(files) == 0 || files[0].Commit == nil {
+		return nil
+	}
+	for _, f := range files[1:] {
+		if f.Commit == nil || f.Commit.SHA != files[0].Commit.SHA {
+			return nil
+		}
+	}
+	return files[0].Commit
+}
+
+func statusTitle(status types.FileStatus) string {
+	if status == types.StatusCopied {
+		return "Copied"
+	}
+	return "Renamed"
+}
+
+// modelResponse is the response as the model writes it. Only the fields the
+// model is asked for are decoded, so it cannot set the ones golum fills in,
+// e.g. Unanchored. Severity is decoded apart so that an absent severity can
+// be told from an explicit "info".
+type modelResponse struct {
+	Comments []modelComment `json:"comments"`
+	Summary  string         `json:"summary"`
+}
+
+type modelComment struct {
+	FilePath string          `json:"filePath"`
+	Line     int             `json:"line"`
+	Code     string          `json:"code"`
+	Comment  string          `json:"comment"`
+	Severity json.RawMessage `json:"severity"`
+}
+
+func ParseBatchResponse(raw string) (types.AIReviewResponse, error) {
 	jsonPayload := raw
 	if matches := fencedJSON.FindStringSubmatch(raw); len(matches) == 2 {
 		jsonPayload = matches[1]
 	}
 
-	var parsed types.AIReviewResponse
+	var parsed modelResponse
 	if err := json.Unmarshal([]byte(jsonPayload), &parsed); err != nil {
 		return types.AIReviewResponse{
 			Comments: nil,
 			Summary:  "Failed to parse AI response",
-		}
+		}, fmt.Errorf("parse AI response: %w", err)
 	}
 
-	return parsed
+	response := types.AIReviewResponse{Summary: parsed.Summary}
+	for _, c := range parsed.Comments {
+		comment := types.ReviewComment{FilePath: c.FilePath, Line: c.Line, Code: c.Code, Comment: c.Comment}
+		var severity string
+		if json.Unmarshal(c.Severity, &severity) == nil && strings.TrimSpace(severity) != "" {
+			comment.Severity = types.ParseSeverity(severity)
+		} else if prefixed, ok := types.ParseSeverityPrefix(comment.Comment); ok {
+			// Models sometimes leave severity out but keep the prefix in the comment
+			comment.Severity = prefixed
+		}
+		response.Comments = append(response.Comments, comment)
+	}
+	return response, nil
 }
@@ -0,0 +1,13 @@
+package ai
+
+import "unicode/utf8"
+
+// charsPerToken is deliberately lower than the ~4 characters usually quoted
+// for English prose: code, diffs and line-number gutters tokenize worse.
+const charsPerToken = 3
+
+// EstimateTokens returns a conservative token count for text without
+// depending on the tokenizer of a particular model.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
@@ -0,0 +1,101 @@
+package bestpractices
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// ruleFence opens a block holding an AST rule in a rules file.
+const ruleFence = "```golum-rule"
+
+// extractASTRules parses the AST rule blocks of a rules file and returns the
+// file without them, since the model does not need to check these rules. A
+// block starts with `; key: value` lines, which are comments for the query
+// that follows:
+//
+//	```golum-rule
+//	; name: no-any
+//	; severity: issue
+//	; languages: typescript, tsx
+//	; message: Type this precisely instead of using `{{violation}}`
+//	((predefined_type) @violation (#eq? @violation "any"))
+//	```
+//
+// Rules without languages apply to the languages of the file, if it is
+// named after some.
+func extractASTRules(file string, content []byte, fileLanguages []*lang.Language) ([]byte, []types.ASTRule, error) {
+	var prose []string
+	var rules []types.ASTRule
+	lines := strings.Split(string(content), "\n")
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != ruleFence {
+			prose = append(prose, lines[i])
+			continue
+		}
+		start := i + 1
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "```" {
+			end++
+		}
+		rule, err := parseASTRule(lines[start:end], fileLanguages)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s:%d: %w", file, i+1, err)
+		}
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("%s:%d", filepath.Base(file), i+1)
+		}
+		rule.Source = file
+		rules = append(rules, rule)
+		i = end
+	}
+	return []byte(strings.Join(prose, "\n")), rules, nil
+}
+
+func parseASTRule(block []string, fileLanguages []*lang.Language) (types.ASTRule, error) {
+	rule := types.ASTRule{Severity: types.SeveritySuggestion, Query: strings.Join(block, "\n")}
+	for _, line := range block {
+		header, ok := strings.CutPrefix(strings.TrimSpace(line), ";")
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(header, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name":
+			rule.Name = value
+		case "message":
+			rule.Message = value
+		case "severity":
+			severity, ok := types.ParseSeverityLevel(value)
+			if !ok {
+				return rule, fmt.Errorf("invalid severity %q (expected info, question, suggestion, issue or blocking)", value)
+			}
+			rule.Severity = severity
+		case "languages":
+			for _, name := range strings.Split(value, ",") {
+				name = strings.TrimSpace(name)
+				if lang.ByName(name) == nil {
+					return rule, fmt.Errorf("unknown language %q", name)
+				}
+				rule.Languages = append(rule.Languages, name)
+			}
+		}
+	}
+
+	if rule.Message == "" {
+		return rule, fmt.Errorf("AST rule %q has no message", rule.Name)
+	}
+	if rule.Languages == nil {
+		for _, l := range fileLanguages {
+			rule.Languages = append(rule.Languages, l.Name)
+		}
+	}
+	return rule, nil
+}
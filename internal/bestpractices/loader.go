@@ -6,12 +6,30 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
 )
 
-func LoadBestPractices(path string) (string, error) {
+// Rules are the guideline files loaded from a rules path.
+type Rules struct {
+	files    []ruleFile
+	astRules []types.ASTRule
+}
+
+type ruleFile struct {
+	title     string
+	content   []byte
+	languages []*lang.Language // Empty when the file applies to every language
+}
+
+// Load reads a single .md rules file, or every .md file of a directory.
+// Files named after a language (e.g. go.md, react.md) only apply to that
+// language; see For. AST rule blocks are set apart; see ASTRules.
+func Load(path string) (*Rules, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return "", fmt.Errorf("stat %s: %w", path, err)
+		return nil, fmt.Errorf("stat %s: %w", path, err)
 	}
 
 	var files []string
@@ -21,46 +39,87 @@ func LoadBestPractices(path string) (string, error) {
 		pattern := filepath.Join(path, "*.md")
 		matches, err := filepath.Glob(pattern)
 		if err != nil {
-			return "", fmt.Errorf("glob markdown: %w", err)
+			return nil, fmt.Errorf("glob markdown: %w", err)
 		}
 		if len(matches) == 0 {
-			return "", fmt.Errorf("no markdown files found in directory %s", path)
+			return nil, fmt.Errorf("no markdown files found in directory %s", path)
 		}
 		files = matches
 	} else {
 		// Single file
 		if !strings.HasSuffix(path, ".md") {
-			return "", fmt.Errorf("rules file must be a .md file, got: %s", path)
+			return nil, fmt.Errorf("rules file must be a .md file, got: %s", path)
 		}
 		files = []string{path}
 	}
 
 	sort.Strings(files)
 
-	var builder strings.Builder
-
+	rules := &Rules{}
 	for _, file := range files {
 		content, readErr := os.ReadFile(file)
 		if readErr != nil {
-			return "", fmt.Errorf("read %s: %w", file, readErr)
+			return nil, fmt.Errorf("read %s: %w", file, readErr)
 		}
 
 		base := filepath.Base(file)
 		title := strings.TrimSuffix(base, filepath.Ext(base))
 		title = strings.TrimSpace(splitCamelCase(title))
 
+		rf := ruleFile{title: title}
+		if info.IsDir() {
+			// A single file given explicitly always applies
+			rf.languages = lang.ForRuleFile(file)
+		}
+		prose, astRules, err := extractASTRules(file, content, rf.languages)
+		if err != nil {
+			return nil, err
+		}
+		rf.content = prose
+		rules.files = append(rules.files, rf)
+		rules.astRules = append(rules.astRules, astRules...)
+	}
+
+	return rules, nil
+}
+
+// For renders the rules that apply to files of the given languages: the
+// general files and the files of those languages.
+func (r *Rules) For(languages []*lang.Language) string {
+	var builder strings.Builder
+
+	for _, file := range r.files {
+		if !file.appliesTo(languages) {
+			continue
+		}
 		builder.WriteString("\n# ")
-		builder.WriteString(title)
+		builder.WriteString(file.title)
 		builder.WriteString("\n\n")
-		builder.Write(content)
+		builder.Write(file.content)
 		builder.WriteString("\n\n")
 	}
 
-	if builder.Len() == 0 {
-		return "", fmt.Errorf("no markdown guidelines found in %s", path)
-	}
+	return builder.String()
+}
+
+// ASTRules returns the rules of every file that are checked with a
+// tree-sitter query rather than by the model.
+func (r *Rules) ASTRules() []types.ASTRule {
+	return r.astRules
+}
 
-	return builder.String(), nil
+func (f ruleFile) appliesTo(languages []*lang.Language) bool {
+	if len(f.languages) == 0 {
+		return true
+	}
+	for _, l := range languages {
+		for _, fl := range f.languages {
+			if l == fl {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func splitCamelCase(input string) string {
@@ -5,92 +5,197 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"strconv"
 	"strings"
 
+	"github.com/lawndlwd/golum/internal/lang"
 	"github.com/lawndlwd/golum/internal/parser"
 	"github.com/lawndlwd/golum/internal/types"
 )
 
+// ParseChangedLines returns the new-side numbers of the added lines of a diff.
 func ParseChangedLines(diff string) []int {
 	var changedLines []int
-	lines := strings.Split(diff, "\n")
-	currentLine := 0
-
-	for _, line := range lines {
-		if strings.HasPrefix(line, "@@") {
-			// Parse hunk header: @@ -start,count +start,count @@
-			re := regexp.MustCompile(`@@ -\d+,\d+ \+(\d+),\d+ @@`)
-			matches := re.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				if start, err := strconv.Atoi(matches[1]); err == nil {
-					currentLine = start
-				}
-			}
-			continue
-		}
-
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			changedLines = append(changedLines, currentLine)
-			currentLine++
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-			// Skip decrement for deletions
-		} else if strings.HasPrefix(line, " ") {
-			currentLine++
-		}
+	for _, hunk := range ParseHunks(diff) {
+		changedLines = append(changedLines, hunk.ChangedLines()...)
 	}
-
 	return changedLines
 }
 
-func getFileContent(repoPath, filePath, ref string) (string, error) {
-	// Try to get content from git
-	cmd := exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:%s", ref, filePath))
-	output, err := cmd.Output()
-	if err != nil {
-		// Fallback to reading from file system
-		fullPath := filepath.Join(repoPath, filePath)
-		content, err := os.ReadFile(fullPath)
+// ReadContent returns the content of path as seen by src: the working tree,
+// the index, or a revision. Hunk-only sources cannot be read from the
+// repository and return an error; callers use ContentFromHunks instead.
+func ReadContent(repoPath string, src types.ContentSource, path string) (string, error) {
+	switch src.Kind {
+	case types.ContentWorkTree:
+		content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(path)))
 		if err != nil {
 			return "", err
 		}
 		return string(content), nil
+	case types.ContentIndex:
+		return gitShow(repoPath, ":"+path)
+	case types.ContentRevision:
+		return gitShow(repoPath, src.Rev+":"+path)
+	default:
+		return "", fmt.Errorf("no repository content for %s (%s)", path, src)
+	}
+}
+
+// MatchesBase reports whether the removed and context lines of a diff are
+// those of the file in the base version src, so a patch made elsewhere can
+// be given context from it. Added files always match.
+func MatchesBase(repoPath string, src types.ContentSource, diff types.FileDiff) bool {
+	if diff.Status == types.StatusAdded {
+		return true
+	}
+	oldPath := diff.OldPath
+	if oldPath == "" {
+		oldPath = diff.NewPath
+	}
+	content, err := ReadContent(repoPath, src, oldPath)
+	if err != nil {
+		return false
+	}
+	hunks := diff.Hunks
+	if hunks == nil {
+		hunks = ParseHunks(diff.Diff)
+	}
+	lines := strings.Split(content, "\n")
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == types.LineAdded {
+				continue
+			}
+			if line.OldLine < 1 || line.OldLine > len(lines) || strings.TrimSuffix(lines[line.OldLine-1], "\r") != strings.TrimSuffix(line.Content, "\r") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func gitShow(repoPath, object string) (string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "show", object)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git show %s: %w", object, err)
 	}
 	return string(output), nil
 }
 
-func EnrichDiffWithContext(repoPath string, diff types.FileDiff, targetBranch string, p *parser.Parser) (types.FileDiff, *types.CodeContext, error) {
-	// Parse changed lines from diff
-	changedLines := ParseChangedLines(diff.Diff)
+// ContentFromHunks rebuilds the new side of a file from the context and added
+// lines of its hunks. Lines outside the hunks are left empty so line numbers
+// still match the diff.
+func ContentFromHunks(hunks []types.Hunk) string {
+	known := make(map[int]string)
+	last := 0
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == types.LineRemoved {
+				continue
+			}
+			known[line.NewLine] = line.Content
+			last = max(last, line.NewLine)
+		}
+	}
 
-	// Get current file content
-	currentContent, err := getFileContent(repoPath, diff.NewPath, "HEAD")
+	lines := make([]string, last)
+	for num, text := range known {
+		if num >= 1 {
+			lines[num-1] = text
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewContent returns the new version of a file as the diff describes it.
+// Without a repository (e.g. reviewing a patch artifact) the hunks' own lines
+// are the only content available.
+func NewContent(repoPath string, diff types.FileDiff) (string, error) {
+	if !HasFullContent(repoPath, diff) {
+		if diff.Hunks == nil {
+			diff.Hunks = ParseHunks(diff.Diff)
+		}
+		return ContentFromHunks(diff.Hunks), nil
+	}
+	return ReadContent(repoPath, diff.NewSource, diff.NewPath)
+}
+
+// HasFullContent reports whether NewContent returns the whole file rather
+// than the lines of its hunks, which do not parse as a whole.
+func HasFullContent(repoPath string, diff types.FileDiff) bool {
+	return repoPath != "" && diff.NewSource.Kind != types.ContentHunks
+}
+
+// EnrichDiffWithContext reads the file from the version the diff's line
+// numbers refer to and extracts context for its changed lines. When lines were
+// removed, context for them is read from the base version as well.
+func EnrichDiffWithContext(repoPath string, diff types.FileDiff, p *parser.Parser) (types.FileDiff, *types.CodeContext, error) {
+	// Deleted and binary files have no new content to read
+	switch diff.Status {
+	case types.StatusDeleted, types.StatusBinary:
+		return diff, nil, fmt.Errorf("no content to enrich for %s file %s", diff.Status, diff.NewPath)
+	}
+
+	if diff.Hunks == nil {
+		diff.Hunks = ParseHunks(diff.Diff)
+	}
+	changedLines := diff.ChangedLines()
+
+	currentContent, err := NewContent(repoPath, diff)
 	if err != nil {
 		return diff, nil, err
 	}
 
 	// Determine language (for prompt decoration only)
-	switch {
-	case strings.HasSuffix(diff.NewPath, ".tsx"):
-		diff.Language = "tsx"
-	case strings.HasSuffix(diff.NewPath, ".ts"):
-		diff.Language = "typescript"
-	case strings.HasSuffix(diff.NewPath, ".jsx"):
-		diff.Language = "jsx"
-	case strings.HasSuffix(diff.NewPath, ".js"):
-		diff.Language = "javascript"
+	if l := lang.ForPath(diff.NewPath); l != nil {
+		diff.Language = l.Name
 	}
 
 	if p == nil {
 		// If parser is nil, create a simple context without tree-sitter
 		ctx := &types.CodeContext{
 			ChangedLines: changedLines,
-			Surrounding:  make(map[int]string),
 		}
 		return diff, ctx, nil
 	}
 
 	ctx := p.AnalyzeCodeContext(currentContent, changedLines, diff.NewPath)
+	ctx.Removed = removedContext(repoPath, diff, p)
+	if !HasFullContent(repoPath, diff) {
+		// The gaps between hunks would be reported as syntax errors
+		ctx.SyntaxErrors = nil
+	}
 	return diff, ctx, nil
 }
+
+// removedContext returns the regions of the base version around the removed
+// lines, which are marked like changed lines. It is best effort: a base that
+// cannot be read yields no context rather than failing the file.
+func removedContext(repoPath string, diff types.FileDiff, p *parser.Parser) []types.ContextRegion {
+	if repoPath == "" || diff.Deletions == 0 || diff.Status == types.StatusAdded || diff.OldSource.Kind == types.ContentHunks {
+		return nil
+	}
+
+	var removed []int
+	for _, hunk := range diff.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == types.LineRemoved {
+				removed = append(removed, line.OldLine)
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	oldPath := diff.OldPath
+	if oldPath == "" {
+		oldPath = diff.NewPath
+	}
+	base, err := ReadContent(repoPath, diff.OldSource, oldPath)
+	if err != nil {
+		return nil
+	}
+	return p.AnalyzeCodeContext(base, removed, oldPath).Regions
+}
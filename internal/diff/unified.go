@@ -0,0 +1,403 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// Counts are optional: "@@ -1 +1 @@" and "@@ -0,0 +1 @@" are valid headers.
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseHunks parses every hunk of a (single or multi file) unified diff.
+func ParseHunks(text string) []types.Hunk {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var hunks []types.Hunk
+	for i := 0; i < len(lines); i++ {
+		if !hunkHeader.MatchString(lines[i]) {
+			continue
+		}
+		hunk, last := parseHunk(lines, i)
+		hunks = append(hunks, hunk)
+		i = last
+	}
+	return hunks
+}
+
+// parseHunk parses the hunk whose header is lines[start]. It consumes exactly
+// the lines announced by the header, so trailers such as the format-patch
+// signature ("-- ") are never mistaken for changes, and returns the index of
+// the last consumed line.
+func parseHunk(lines []string, start int) (types.Hunk, int) {
+	m := hunkHeader.FindStringSubmatch(lines[start])
+	hunk := types.Hunk{
+		OldStart: atoi(m[1]),
+		OldLines: hunkCount(m[2]),
+		NewStart: atoi(m[3]),
+		NewLines: hunkCount(m[4]),
+		Section:  strings.TrimSpace(m[5]),
+	}
+
+	oldLine, newLine := hunk.OldStart, hunk.NewStart
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	i := start
+	for i+1 < len(lines) && (oldLeft > 0 || newLeft > 0 || strings.HasPrefix(lines[i+1], `\`)) {
+		i++
+		body := lines[i]
+		switch {
+		case strings.HasPrefix(body, `\`):
+			// "\ No newline at end of file" applies to the previous line
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].NoNewline = true
+			}
+		case strings.HasPrefix(body, "+"):
+			hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: types.LineAdded, Content: body[1:], NewLine: newLine})
+			newLine++
+			newLeft--
+		case strings.HasPrefix(body, "-"):
+			hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: types.LineRemoved, Content: body[1:], OldLine: oldLine})
+			oldLine++
+			oldLeft--
+		default:
+			// Context line; some tools strip the leading space of empty lines
+			content := strings.TrimPrefix(body, " ")
+			hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: types.LineContext, Content: content, OldLine: oldLine, NewLine: newLine})
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		}
+	}
+	return hunk, i
+}
+
+// Header returns the file header of a single-file diff: everything before the
+// first hunk.
+func Header(text string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if hunkHeader.MatchString(strings.TrimRight(line, "\n")) {
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// ParseUnified splits the output of `git diff` (or any unified diff, including
+// `git format-patch` mails) into one FileDiff per file. Additions and deletions
+// are counted from hunk bodies only, never from the ---/+++ headers.
+func ParseUnified(text string) []types.FileDiff {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var (
+		files   []types.FileDiff
+		current *fileBuilder
+	)
+	flush := func() {
+		if current != nil {
+			files = append(files, current.build())
+			current = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			current = newFileBuilder()
+			current.oldPath, current.newPath = parseGitHeader(strings.TrimPrefix(line, "diff --git "))
+			current.write(line)
+			continue
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			// A plain unified diff starts directly with the file headers
+			if current == nil || current.inHunks {
+				flush()
+				current = newFileBuilder()
+			}
+			current.oldPath = headerPath(strings.TrimPrefix(line, "--- "), current.oldPath)
+			current.newPath = headerPath(strings.TrimPrefix(lines[i+1], "+++ "), current.newPath)
+			if current.oldPath == "" {
+				current.status = types.StatusAdded
+			}
+			if current.newPath == "" {
+				current.status = types.StatusDeleted
+			}
+			current.write(line)
+			current.write(lines[i+1])
+			i++
+			continue
+		}
+
+		if current == nil {
+			// Mail headers, commit messages and other preamble
+			continue
+		}
+
+		if hunkHeader.MatchString(line) {
+			hunk, last := parseHunk(lines, i)
+			current.inHunks = true
+			current.hunks = append(current.hunks, hunk)
+			for ; i <= last; i++ {
+				current.write(lines[i])
+			}
+			i = last
+			continue
+		}
+
+		if current.inHunks {
+			// Anything after the hunks that is not a new file belongs to the preamble
+			// of the next mail (or is trailing noise)
+			continue
+		}
+
+		current.parseExtendedHeader(line)
+		current.write(line)
+	}
+	flush()
+
+	return mergeTypeChanges(files)
+}
+
+type fileBuilder struct {
+	oldPath    string
+	newPath    string
+	status     types.FileStatus
+	similarity int
+	hunks      []types.Hunk
+	inHunks    bool
+	body       strings.Builder
+}
+
+func newFileBuilder() *fileBuilder {
+	return &fileBuilder{status: types.StatusModified}
+}
+
+func (f *fileBuilder) write(line string) {
+	f.body.WriteString(line)
+	f.body.WriteString("\n")
+}
+
+func (f *fileBuilder) parseExtendedHeader(line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		f.status = types.StatusAdded
+	case strings.HasPrefix(line, "deleted file mode "):
+		f.status = types.StatusDeleted
+	case strings.HasPrefix(line, "rename from "):
+		f.status = types.StatusRenamed
+		f.oldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		f.status = types.StatusRenamed
+		f.newPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "copy from "):
+		f.status = types.StatusCopied
+		f.oldPath = unquotePath(strings.TrimPrefix(line, "copy from "))
+	case strings.HasPrefix(line, "copy to "):
+		f.status = types.StatusCopied
+		f.newPath = unquotePath(strings.TrimPrefix(line, "copy to "))
+	case strings.HasPrefix(line, "similarity index "):
+		f.similarity, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "similarity index "), "%"))
+	case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+		f.status = types.StatusBinary
+	}
+}
+
+func (f *fileBuilder) build() types.FileDiff {
+	oldPath, newPath := f.oldPath, f.newPath
+	if oldPath == "" {
+		oldPath = newPath
+	}
+	if newPath == "" {
+		newPath = oldPath
+	}
+	additions, deletions := 0, 0
+	for _, h := range f.hunks {
+		a, d := h.Count()
+		additions += a
+		deletions += d
+	}
+	return types.FileDiff{
+		OldPath:    oldPath,
+		NewPath:    newPath,
+		Status:     f.status,
+		Similarity: f.similarity,
+		Diff:       f.body.String(),
+		Hunks:      f.hunks,
+		Additions:  additions,
+		Deletions:  deletions,
+	}
+}
+
+// mergeTypeChanges folds the delete + add pair git emits for a type change
+// (e.g. a file replaced by a symlink) into a single entry.
+func mergeTypeChanges(files []types.FileDiff) []types.FileDiff {
+	var result []types.FileDiff
+	for i := 0; i < len(files); i++ {
+		file := files[i]
+		if i+1 < len(files) && file.Status == types.StatusDeleted &&
+			files[i+1].Status == types.StatusAdded && files[i+1].NewPath == file.NewPath {
+			next := files[i+1]
+			file.Status = types.StatusTypeChanged
+			file.Diff += next.Diff
+			file.Hunks = append(file.Hunks, next.Hunks...)
+			file.Additions += next.Additions
+			file.Deletions += next.Deletions
+			i++
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// parseGitHeader extracts both paths from "a/<old> b/<new>".
+func parseGitHeader(rest string) (string, string) {
+	if strings.HasPrefix(rest, `"`) {
+		if end := closingQuote(rest); end > 0 {
+			oldPath := unquotePath(rest[:end+1])
+			newPath := unquotePath(strings.TrimSpace(rest[end+1:]))
+			return oldPath, newPath
+		}
+	}
+
+	// Unquoted paths with identical names on both sides: "a/P b/P"
+	if len(rest)%2 == 1 {
+		half := len(rest) / 2
+		left, right := rest[:half], rest[half+1:]
+		if stripPrefix(left) == stripPrefix(right) {
+			return stripPrefix(left), stripPrefix(right)
+		}
+	}
+
+	if idx := strings.Index(rest, " b/"); idx >= 0 {
+		return stripPrefix(rest[:idx]), stripPrefix(rest[idx+1:])
+	}
+	return "", ""
+}
+
+// headerPath parses a ---/+++ path. /dev/null yields an empty path.
+func headerPath(raw, fallback string) string {
+	raw = strings.TrimRight(raw, "\t")
+	if idx := strings.Index(raw, "\t"); idx >= 0 {
+		// Plain diffs append a timestamp after a tab
+		raw = raw[:idx]
+	}
+	if raw == "/dev/null" {
+		return ""
+	}
+	path := unquotePath(raw)
+	if path == "" {
+		return fallback
+	}
+	return stripPrefix(path)
+}
+
+func stripPrefix(path string) string {
+	path = unquotePath(path)
+	if len(path) > 2 && path[1] == '/' && (path[0] == 'a' || path[0] == 'b') {
+		return path[2:]
+	}
+	return path
+}
+
+// unquotePath undoes git's C-style quoting of unusual file names.
+func unquotePath(path string) string {
+	path = strings.TrimSpace(path)
+	if len(path) >= 2 && strings.HasPrefix(path, `"`) && strings.HasSuffix(path, `"`) {
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			return unquoted
+		}
+	}
+	return path
+}
+
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func atoi(raw string) int {
+	n, _ := strconv.Atoi(raw)
+	return n
+}
+
+func hunkCount(raw string) int {
+	if raw == "" {
+		return 1
+	}
+	count, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// NewFileDiff synthesizes the diff git would print for a brand-new file, so
+// untracked files go through the same parsing path as tracked ones.
+func NewFileDiff(path string, content []byte) types.FileDiff {
+	quoted := quotePath("b/" + path)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("diff --git %s %s\n", quotePath("a/"+path), quoted))
+	b.WriteString("new file mode 100644\n")
+
+	if isBinary(content) {
+		b.WriteString(fmt.Sprintf("Binary files /dev/null and %s differ\n", quoted))
+		return parseSingle(b.String(), path)
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if text == "" {
+		return parseSingle(b.String(), path)
+	}
+	missingNewline := !strings.HasSuffix(text, "\n")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	b.WriteString("--- /dev/null\n")
+	b.WriteString(fmt.Sprintf("+++ %s\n", quoted))
+	b.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(lines)))
+	for _, line := range lines {
+		b.WriteString("+")
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if missingNewline {
+		b.WriteString("\\ No newline at end of file\n")
+	}
+	return parseSingle(b.String(), path)
+}
+
+func parseSingle(text, path string) types.FileDiff {
+	if files := ParseUnified(text); len(files) == 1 {
+		return files[0]
+	}
+	return types.FileDiff{OldPath: path, NewPath: path, Status: types.StatusAdded, Diff: text}
+}
+
+// isBinary uses git's heuristic: a NUL byte in the first 8000 bytes.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0
+}
+
+func quotePath(path string) string {
+	for _, r := range path {
+		if r < 0x20 || r == '"' || r == '\\' || r > 0x7e {
+			return strconv.Quote(path)
+		}
+	}
+	return path
+}
@@ -0,0 +1,176 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+func TestParseUnified(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		oldPath    string
+		newPath    string
+		status     types.FileStatus
+		additions  int
+		deletions  int
+		similarity int
+	}{
+		{
+			name:    "modified",
+			text:    "diff --git a/src/app.ts b/src/app.ts\nindex 1111111..2222222 100644\n--- a/src/app.ts\n+++ b/src/app.ts\n@@ -1,2 +1,2 @@\n const a = 1\n-const b = 2\n+const b = 3\n",
+			oldPath: "src/app.ts", newPath: "src/app.ts", status: types.StatusModified,
+			additions: 1, deletions: 1,
+		},
+		{
+			name:    "quoted paths",
+			text:    "diff --git \"a/src/caf\\303\\251 menu.ts\" \"b/src/caf\\303\\251 menu.ts\"\n--- \"a/src/caf\\303\\251 menu.ts\"\n+++ \"b/src/caf\\303\\251 menu.ts\"\n@@ -1 +1 @@\n-a\n+b\n",
+			oldPath: "src/café menu.ts", newPath: "src/café menu.ts", status: types.StatusModified,
+			additions: 1, deletions: 1,
+		},
+		{
+			name:    "unquoted paths with spaces",
+			text:    "diff --git a/my dir/a b.ts b/my dir/a b.ts\n--- a/my dir/a b.ts\n+++ b/my dir/a b.ts\n@@ -1 +1 @@\n-a\n+b\n",
+			oldPath: "my dir/a b.ts", newPath: "my dir/a b.ts", status: types.StatusModified,
+			additions: 1, deletions: 1,
+		},
+		{
+			name:    "new file without counts",
+			text:    "diff --git a/new.ts b/new.ts\nnew file mode 100644\n--- /dev/null\n+++ b/new.ts\n@@ -0,0 +1 @@\n+export const x = 1\n",
+			oldPath: "new.ts", newPath: "new.ts", status: types.StatusAdded,
+			additions: 1,
+		},
+		{
+			name:    "deleted file",
+			text:    "diff --git a/old.ts b/old.ts\ndeleted file mode 100644\n--- a/old.ts\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+			oldPath: "old.ts", newPath: "old.ts", status: types.StatusDeleted,
+			deletions: 2,
+		},
+		{
+			name:    "rename",
+			text:    "diff --git a/a.ts b/b.ts\nsimilarity index 90%\nrename from a.ts\nrename to b.ts\n--- a/a.ts\n+++ b/b.ts\n@@ -1 +1 @@\n-a\n+b\n",
+			oldPath: "a.ts", newPath: "b.ts", status: types.StatusRenamed,
+			additions: 1, deletions: 1, similarity: 90,
+		},
+		{
+			name:    "format-patch trailer",
+			text:    "From 1234 Mon Sep 17 00:00:00 2001\nSubject: [PATCH] change\n\n---\n a.ts | 2 +-\n\ndiff --git a/a.ts b/a.ts\n--- a/a.ts\n+++ b/a.ts\n@@ -1 +1 @@\n-a\n+b\n-- \n2.40.0\n\n",
+			oldPath: "a.ts", newPath: "a.ts", status: types.StatusModified,
+			additions: 1, deletions: 1,
+		},
+		{
+			name:    "plain unified diff",
+			text:    "--- src/a.ts\t2024-01-01 00:00:00\n+++ src/a.ts\t2024-01-02 00:00:00\n@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+			oldPath: "src/a.ts", newPath: "src/a.ts", status: types.StatusModified,
+			additions: 1,
+		},
+		{
+			name:    "binary",
+			text:    "diff --git a/logo.png b/logo.png\nindex 1111111..2222222 100644\nBinary files a/logo.png and b/logo.png differ\n",
+			oldPath: "logo.png", newPath: "logo.png", status: types.StatusBinary,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := ParseUnified(tt.text)
+			if len(files) != 1 {
+				t.Fatalf("ParseUnified returned %d files, want 1", len(files))
+			}
+			f := files[0]
+			if f.OldPath != tt.oldPath || f.NewPath != tt.newPath {
+				t.Errorf("paths = %q -> %q, want %q -> %q", f.OldPath, f.NewPath, tt.oldPath, tt.newPath)
+			}
+			if f.Status != tt.status {
+				t.Errorf("status = %v, want %v", f.Status, tt.status)
+			}
+			if f.Additions != tt.additions || f.Deletions != tt.deletions {
+				t.Errorf("counts = +%d -%d, want +%d -%d", f.Additions, f.Deletions, tt.additions, tt.deletions)
+			}
+			if f.Similarity != tt.similarity {
+				t.Errorf("similarity = %d, want %d", f.Similarity, tt.similarity)
+			}
+		})
+	}
+}
+
+func TestParseUnifiedSplitsFiles(t *testing.T) {
+	text := "diff --git a/a.ts b/a.ts\n--- a/a.ts\n+++ b/a.ts\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/b.ts b/b.ts\n--- a/b.ts\n+++ b/b.ts\n@@ -1 +1,2 @@\n b\n+c\n"
+	files := ParseUnified(text)
+	if len(files) != 2 || files[0].NewPath != "a.ts" || files[1].NewPath != "b.ts" {
+		t.Fatalf("ParseUnified = %+v, want a.ts and b.ts", files)
+	}
+	if files[1].Additions != 1 || files[1].Deletions != 0 {
+		t.Errorf("b.ts counts = +%d -%d, want +1 -0", files[1].Additions, files[1].Deletions)
+	}
+}
+
+func TestParseHunks(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		lines []types.DiffLine
+	}{
+		{
+			name: "added file without counts",
+			text: "@@ -0,0 +1 @@\n+x\n",
+			lines: []types.DiffLine{
+				{Kind: types.LineAdded, Content: "x", NewLine: 1},
+			},
+		},
+		{
+			name: "context and changes",
+			text: "@@ -10,3 +10,3 @@ func main() {\n a\n-b\n+c\n d\n",
+			lines: []types.DiffLine{
+				{Kind: types.LineContext, Content: "a", OldLine: 10, NewLine: 10},
+				{Kind: types.LineRemoved, Content: "b", OldLine: 11},
+				{Kind: types.LineAdded, Content: "c", NewLine: 11},
+				{Kind: types.LineContext, Content: "d", OldLine: 12, NewLine: 12},
+			},
+		},
+		{
+			name: "format-patch signature is not a removed line",
+			text: "@@ -1 +1 @@\n-a\n+b\n-- \n2.40.0\n",
+			lines: []types.DiffLine{
+				{Kind: types.LineRemoved, Content: "a", OldLine: 1},
+				{Kind: types.LineAdded, Content: "b", NewLine: 1},
+			},
+		},
+		{
+			name: "no newline at end of file",
+			text: "@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n",
+			lines: []types.DiffLine{
+				{Kind: types.LineRemoved, Content: "a", OldLine: 1, NoNewline: true},
+				{Kind: types.LineAdded, Content: "a", NewLine: 1},
+			},
+		},
+		{
+			name: "empty context line without its space",
+			text: "@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n",
+			lines: []types.DiffLine{
+				{Kind: types.LineContext, Content: "a", OldLine: 1, NewLine: 1},
+				{Kind: types.LineContext, Content: "", OldLine: 2, NewLine: 2},
+				{Kind: types.LineRemoved, Content: "b", OldLine: 3},
+				{Kind: types.LineAdded, Content: "c", NewLine: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := ParseHunks(tt.text)
+			if len(hunks) != 1 {
+				t.Fatalf("ParseHunks returned %d hunks, want 1", len(hunks))
+			}
+			got := hunks[0].Lines
+			if len(got) != len(tt.lines) {
+				t.Fatalf("lines = %+v, want %+v", got, tt.lines)
+			}
+			for i := range got {
+				if got[i] != tt.lines[i] {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], tt.lines[i])
+				}
+			}
+		})
+	}
+}
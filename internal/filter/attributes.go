@@ -0,0 +1,52 @@
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// attributeReasons returns, for every path that should not be reviewed
+// according to .gitattributes, the attribute that excludes it. All paths are
+// resolved with a single `git check-attr` call.
+func attributeReasons(repoPath string, paths []string) (map[string]string, error) {
+	if repoPath == "" || len(paths) == 0 {
+		return nil, nil
+	}
+
+	var stdin bytes.Buffer
+	for _, p := range paths {
+		stdin.WriteString(p)
+		stdin.WriteByte(0)
+	}
+
+	cmd := exec.Command("git", "-C", filepath.Clean(repoPath), "check-attr", "-z", "--stdin", "linguist-generated", "linguist-vendored", "diff")
+	cmd.Stdin = &stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git check-attr: %w", err)
+	}
+
+	// Output is a sequence of <path> NUL <attribute> NUL <value> NUL
+	reasons := make(map[string]string)
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		path, attr, value := fields[i], fields[i+1], fields[i+2]
+		if _, done := reasons[path]; done {
+			continue
+		}
+		switch attr {
+		case "linguist-generated", "linguist-vendored":
+			if value == "set" || value == "true" {
+				reasons[path] = attr + " in .gitattributes"
+			}
+		case "diff":
+			if value == "unset" {
+				reasons[path] = "-diff in .gitattributes"
+			}
+		}
+	}
+	return reasons, nil
+}
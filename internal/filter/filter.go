@@ -1,59 +1,190 @@
 package filter
 
 import (
-	"strings"
+	"fmt"
+	"path/filepath"
 
+	"github.com/lawndlwd/golum/internal/lang"
 	"github.com/lawndlwd/golum/internal/types"
 )
 
-func FilterEligible(files []types.FileDiff, limit int) []types.FileDiff {
-	var result []types.FileDiff
+// IgnoreFileName is the gitignore-style file read from the repository root.
+const IgnoreFileName = ".golumignore"
+
+// DefaultExcludes are applied before .golumignore, so a "!" pattern there can
+// re-include them.
+var DefaultExcludes = []string{
+	"node_modules/",
+	"dist/",
+	".gitlab/",
+	"*.md",
+	"*.json",
+}
+
+type Options struct {
+	RepoPath string // Empty when reviewing a patch without a repository
+	Include  []string
+	Exclude  []string
+	MaxFiles int // 0 means no limit
+}
+
+// Decision records why a file was kept or dropped.
+type Decision struct {
+	Path   string
+	Kept   bool
+	Reason string
+}
+
+type Filter struct {
+	opts     Options
+	excludes *Matcher
+	includes *Matcher
+}
 
+func New(opts Options) (*Filter, error) {
+	excludes := NewMatcher(DefaultExcludes, "default")
+
+	if opts.RepoPath != "" {
+		if err := excludes.AddFile(filepath.Join(opts.RepoPath, IgnoreFileName), IgnoreFileName); err != nil {
+			return nil, err
+		}
+	}
+	excludes.Add(opts.Exclude, "--exclude")
+
+	return &Filter{
+		opts:     opts,
+		excludes: excludes,
+		includes: NewMatcher(opts.Include, "--include"),
+	}, nil
+}
+
+// Apply returns the diffs eligible for review and a decision for every input.
+func (f *Filter) Apply(files []types.FileDiff) ([]types.FileDiff, []Decision) {
+	var paths []string
 	for _, diff := range files {
-		path := diff.NewPath
-		if path == "" {
-			path = diff.OldPath
+		paths = append(paths, diffPath(diff))
+	}
+	attributes, err := attributeReasons(f.opts.RepoPath, paths)
+	if err != nil {
+		// Attributes are an optimization; review everything else normally
+		fmt.Printf("⚠️  Could not read .gitattributes: %v\n", err)
+	}
+
+	var result []types.FileDiff
+	var decisions []Decision
+	for _, diff := range files {
+		path := diffPath(diff)
+		reason, keep := f.decide(diff, path, attributes)
+		if keep && f.opts.MaxFiles > 0 && len(result) >= f.opts.MaxFiles {
+			reason, keep = fmt.Sprintf("over the --max-files limit of %d", f.opts.MaxFiles), false
 		}
-		if shouldSkip(path) {
-			continue
+		if keep {
+			result = append(result, diff)
 		}
-		result = append(result, diff)
-		if limit > 0 && len(result) >= limit {
-			break
+		decisions = append(decisions, Decision{Path: path, Kept: keep, Reason: reason})
+	}
+
+	return result, decisions
+}
+
+// Wants reports whether a file may be reviewed judging by its path alone:
+// it is in a supported language, not excluded and, with --include patterns,
+// included. It lets files be skipped before their content is read.
+func (f *Filter) Wants(path string) bool {
+	if matched, _ := f.excludes.Match(path); matched {
+		return false
+	}
+	if !f.includes.Empty() {
+		if matched, _ := f.includes.Match(path); !matched {
+			return false
 		}
 	}
+	return supportedLanguage(path)
+}
 
+// Deleted returns the deleted files that would be reviewed if they still had
+// content, e.g. to summarize the declarations they remove.
+func (f *Filter) Deleted(files []types.FileDiff) []types.FileDiff {
+	var deleted []types.FileDiff
+	var paths []string
+	for _, diff := range files {
+		if diff.Status == types.StatusDeleted {
+			deleted = append(deleted, diff)
+			paths = append(paths, diffPath(diff))
+		}
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+	attributes, _ := attributeReasons(f.opts.RepoPath, paths)
+
+	var result []types.FileDiff
+	for _, diff := range deleted {
+		judged := diff
+		judged.Status = types.StatusModified
+		if _, keep := f.decide(judged, diffPath(diff), attributes); keep {
+			result = append(result, diff)
+		}
+	}
 	return result
 }
 
-func shouldSkip(path string) bool {
+func (f *Filter) decide(diff types.FileDiff, path string, attributes map[string]string) (string, bool) {
 	if path == "" {
-		return true
+		return "no path", false
 	}
-	if hasAnySuffix(path, ".md", ".json") {
-		return true
+	if matched, p := f.excludes.Match(path); matched {
+		return fmt.Sprintf("excluded by %q (%s)", p.Text, p.Source), false
 	}
-	if containsAny(path, "node_modules", "dist", ".gitlab") {
-		return true
+	if reason, ok := attributes[path]; ok {
+		return reason, false
 	}
-	// Only process JS/TS files for Tree-sitter parsing
-	return !(hasAnySuffix(path, ".ts", ".tsx", ".js", ".jsx"))
+	if reason, ok := reviewable(diff); !ok {
+		return reason, false
+	}
+	includeReason := ""
+	if !f.includes.Empty() {
+		matched, p := f.includes.Match(path)
+		if !matched {
+			return "does not match any --include pattern", false
+		}
+		includeReason = fmt.Sprintf(", included by %q", p.Text)
+	}
+	if !supportedLanguage(path) {
+		return "unsupported language", false
+	}
+	return "eligible" + includeReason, true
 }
 
-func hasAnySuffix(path string, suffixes ...string) bool {
-	for _, suffix := range suffixes {
-		if len(path) >= len(suffix) && path[len(path)-len(suffix):] == suffix {
-			return true
-		}
+func diffPath(diff types.FileDiff) string {
+	if diff.NewPath != "" {
+		return diff.NewPath
 	}
-	return false
+	return diff.OldPath
 }
 
-func containsAny(path string, needles ...string) bool {
-	for _, needle := range needles {
-		if needle != "" && strings.Contains(path, needle) {
-			return true
-		}
+// reviewable reports whether the diff has content the model can review:
+// deleted and binary files have no new code, and pure renames or copies
+// (100% similarity) have no delta. Whitespace-only files and files whose
+// additions are all moved code have nothing new either.
+func reviewable(diff types.FileDiff) (string, bool) {
+	switch diff.Status {
+	case types.StatusDeleted, types.StatusBinary:
+		return fmt.Sprintf("%s file", diff.Status), false
 	}
-	return false
+	if diff.WhitespaceOnly {
+		return "whitespace-only changes", false
+	}
+	if len(diff.Moves) > 0 && diff.Additions-diff.MovedLines() <= 0 {
+		return "only moved code", false
+	}
+	if diff.Additions+diff.Deletions == 0 {
+		return "no content changes", false
+	}
+	return "", true
+}
+
+// supportedLanguage reports whether the file is in a registered language.
+func supportedLanguage(path string) bool {
+	return lang.ForPath(path) != nil
 }
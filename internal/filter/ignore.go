@@ -0,0 +1,188 @@
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a single gitignore-style pattern.
+type Pattern struct {
+	Text    string // Pattern as written
+	Source  string // Where it comes from, e.g. ".golumignore:3" or "--exclude"
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// Matcher evaluates patterns with gitignore semantics: the last matching
+// pattern wins, "!" re-includes, a trailing "/" only matches directories, and a
+// path is excluded when one of its parent directories is.
+type Matcher struct {
+	patterns []Pattern
+}
+
+// ParsePattern compiles one gitignore line. ok is false for blank lines and
+// comments.
+func ParsePattern(line, source string) (Pattern, bool) {
+	text := strings.TrimRight(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(text, " ") && !strings.HasSuffix(text, `\ `) {
+		text = strings.TrimSuffix(text, " ")
+	}
+	if text == "" || strings.HasPrefix(text, "#") {
+		return Pattern{}, false
+	}
+
+	p := Pattern{Text: text, Source: source}
+	if strings.HasPrefix(text, "!") {
+		p.negate = true
+		text = text[1:]
+	} else if strings.HasPrefix(text, `\!`) || strings.HasPrefix(text, `\#`) {
+		text = text[1:]
+	}
+	if strings.HasSuffix(text, "/") {
+		p.dirOnly = true
+		text = strings.TrimSuffix(text, "/")
+	}
+	if text == "" {
+		return Pattern{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the root
+	anchored := strings.Contains(text, "/")
+	text = strings.TrimPrefix(text, "/")
+
+	prefix := "^(?:.*/)?"
+	if anchored {
+		prefix = "^"
+	}
+	re, err := regexp.Compile(prefix + globToRegexp(text) + "$")
+	if err != nil {
+		return Pattern{}, false
+	}
+	p.re = re
+	return p, true
+}
+
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// NewMatcher compiles the given lines, all attributed to source.
+func NewMatcher(lines []string, source string) *Matcher {
+	m := &Matcher{}
+	m.Add(lines, source)
+	return m
+}
+
+// Add appends patterns; later patterns take precedence over earlier ones.
+func (m *Matcher) Add(lines []string, source string) {
+	for _, line := range lines {
+		if p, ok := ParsePattern(line, source); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// AddFile appends the patterns of a gitignore-style file. A missing file is
+// not an error.
+func (m *Matcher) AddFile(path, name string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if p, ok := ParsePattern(scanner.Text(), fmt.Sprintf("%s:%d", name, lineNum)); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return scanner.Err()
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Match reports whether path (slash separated, relative to the repository
+// root) is matched, along with the deciding pattern.
+func (m *Matcher) Match(path string) (bool, *Pattern) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i <= len(parts); i++ {
+		sub := strings.Join(parts[:i], "/")
+		isDir := i < len(parts)
+
+		matched, pattern := m.matchOne(sub, isDir)
+		if matched && isDir {
+			// Nothing inside an excluded directory can be re-included
+			return true, pattern
+		}
+		if !isDir {
+			return matched, pattern
+		}
+	}
+	return false, nil
+}
+
+func (m *Matcher) matchOne(path string, isDir bool) (bool, *Pattern) {
+	matched := false
+	var decisive *Pattern
+	for i := range m.patterns {
+		p := &m.patterns[i]
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.re.MatchString(path) {
+			matched = !p.negate
+			decisive = p
+		}
+	}
+	return matched, decisive
+}
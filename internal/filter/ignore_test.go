@@ -0,0 +1,48 @@
+package filter
+
+import "testing"
+
+func TestMatcherMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"base name anywhere", []string{"*.gen.ts"}, "src/api/client.gen.ts", true},
+		{"star stops at slashes", []string{"src/*.ts"}, "src/api/client.ts", false},
+		{"anchored", []string{"/dist"}, "packages/dist/index.js", false},
+		{"anchored at root", []string{"/dist"}, "dist/index.js", true},
+		{"leading double star", []string{"**/fixtures"}, "fixtures/a.ts", true},
+		{"leading double star nested", []string{"**/fixtures"}, "src/test/fixtures/a.ts", true},
+		{"middle double star", []string{"src/**/mocks/*.ts"}, "src/mocks/a.ts", true},
+		{"middle double star deep", []string{"src/**/mocks/*.ts"}, "src/a/b/mocks/a.ts", true},
+		{"trailing double star", []string{"vendor/**"}, "vendor/lib/a.go", true},
+		{"directory only", []string{"build/"}, "build", false},
+		{"directory only matches contents", []string{"build/"}, "src/build/out.js", true},
+		{"negation", []string{"*.ts", "!keep.ts"}, "src/keep.ts", false},
+		{"last pattern wins", []string{"!keep.ts", "*.ts"}, "src/keep.ts", true},
+		{"negation under excluded directory", []string{"generated/", "!generated/keep.ts"}, "generated/keep.ts", true},
+		{"negation of directory contents", []string{"generated/*", "!generated/keep.ts"}, "generated/keep.ts", false},
+		{"character class", []string{"file[0-9].ts"}, "file7.ts", true},
+		{"negated character class", []string{"file[!0-9].ts"}, "file7.ts", false},
+		{"escaped bang", []string{`\!important.ts`}, "!important.ts", true},
+		{"comment", []string{"# *.ts"}, "a.ts", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMatcher(tt.patterns, "test")
+			if got, _ := m.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherDecisivePattern(t *testing.T) {
+	m := NewMatcher([]string{"*.ts", "!src/*.ts"}, ".golumignore")
+	matched, p := m.Match("src/a.ts")
+	if matched || p == nil || p.Text != "!src/*.ts" {
+		t.Errorf("Match = %v, %+v, want the negation to decide", matched, p)
+	}
+}
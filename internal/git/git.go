@@ -2,168 +2,276 @@ package git
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
 	"github.com/lawndlwd/golum/internal/types"
 )
 
 type LocalOptions struct {
-	RepoPath        string
-	BaseRef         string
-	TargetBranch    string
+	RepoPath     string
+	BaseRef      string
+	TargetBranch string
+	// IncludeUnstaged reviews the working tree (staged and unstaged changes)
+	// on top of the branch commits instead of HEAD only
 	IncludeUnstaged bool
 	Local           bool
+	// Staged reviews exactly the index against the base, ignoring the
+	// working tree
+	Staged bool
+	// IncludeUntracked adds untracked, non-ignored files as full-add diffs
+	// in local mode
+	IncludeUntracked bool
+	// From and To review an explicit commit range (To defaults to HEAD)
+	From string
+	To   string
+	// KeepUntracked decides from its path whether an untracked file may be
+	// reviewed, so excluded trees are not read; nil keeps every file
+	KeepUntracked func(path string) bool
 }
 
+// maxUntrackedSize is the size above which an untracked file is not read,
+// e.g. a build output or a dump.
+const maxUntrackedSize = 1 << 20
+
+// LocalChanges returns one diff per file, computed by a single `git diff`
+// between a base and one side of the change:
+//
+//	staged:          base = target (or HEAD)       side = index
+//	local:           base = target                 side = working tree
+//	branch:          base = merge-base(target)     side = working tree, or HEAD without IncludeUnstaged
+//	range:           base = from                   side = to
+//	no target:       base = HEAD                   side = working tree
+//
+// Every diff records both sides as content sources, so context is read from
+// the same version the line numbers come from.
 func LocalChanges(opts LocalOptions) ([]types.FileDiff, error) {
 	repo := filepath.Clean(opts.RepoPath)
-	// Use target branch if specified, otherwise compare to HEAD
-	var compareRef string
-	if opts.TargetBranch != "" && opts.TargetBranch != "HEAD" {
-		compareRef = opts.TargetBranch
-	} else {
-		compareRef = "HEAD"
-	}
-	files, err := changedFiles(repo, compareRef, opts.IncludeUnstaged, opts.TargetBranch, opts.Local)
+
+	spec, err := diffSpec(repo, opts)
 	if err != nil {
 		return nil, err
 	}
-	var diffs []types.FileDiff
-	for _, file := range files {
-		diffText, err := diffFile(repo, compareRef, file, opts.IncludeUnstaged, opts.TargetBranch, opts.Local)
-		if err != nil || strings.TrimSpace(diffText) == "" {
-			continue
+
+	diffs, err := runDiff(repo, spec.args...)
+	if err != nil {
+		return nil, err
+	}
+	setSources(diffs, spec.old, spec.new)
+
+	if opts.Local && opts.IncludeUntracked {
+		untracked, err := untrackedFiles(repo, opts.KeepUntracked)
+		if err != nil {
+			return nil, err
 		}
-		additions := countPrefix(diffText, '+')
-		deletions := countPrefix(diffText, '-')
-		diffs = append(diffs, types.FileDiff{
-			OldPath:   file,
-			NewPath:   file,
-			Diff:      diffText,
-			Additions: additions,
-			Deletions: deletions,
-		})
+		diffs = append(diffs, untracked...)
 	}
+
 	return diffs, nil
 }
 
-func changedFiles(repo, compareRef string, includeUnstaged bool, targetBranch string, local bool) ([]string, error) {
-	var stdout []byte
-	var err error
+// rangeSpec is the `git diff` invocation of a mode and where its two sides live.
+type rangeSpec struct {
+	args []string
+	old  types.ContentSource
+	new  types.ContentSource
+}
 
-	if local {
-		// Compare working directory + staged changes to origin/targetBranch
-		originBranch := targetBranch
-		args := []string{"-C", repo, "diff", "--name-only", originBranch}
-		stdout, err = exec.Command("git", args...).Output()
-		if err != nil {
-			return nil, fmt.Errorf("git diff %s: %w", originBranch, err)
-		}
-	} else if targetBranch != "" && targetBranch != "HEAD" {
-		// Find the merge base (where your branch diverged from target)
-		mergeBaseCmd := exec.Command("git", "-C", repo, "merge-base", targetBranch, "HEAD")
-		mergeBase, err := mergeBaseCmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("git merge-base: %w", err)
+func diffSpec(repo string, opts LocalOptions) (rangeSpec, error) {
+	hasTarget := opts.TargetBranch != "" && opts.TargetBranch != "HEAD"
+
+	switch {
+	case opts.From != "":
+		to := rangeEnd(opts.To)
+		return rangeSpec{args: []string{opts.From, to}, old: types.Revision(opts.From), new: types.Revision(to)}, nil
+
+	case opts.Staged:
+		// Without an explicit ref git compares the index to HEAD, which also
+		// works before the first commit
+		if !hasTarget {
+			return rangeSpec{args: []string{"--cached"}, old: types.Revision("HEAD"), new: types.Index()}, nil
 		}
-		baseCommit := strings.TrimSpace(string(mergeBase))
+		return rangeSpec{args: []string{"--cached", opts.TargetBranch}, old: types.Revision(opts.TargetBranch), new: types.Index()}, nil
+
+	case opts.Local && hasTarget:
+		// Compare working directory to origin/targetBranch
+		return rangeSpec{args: []string{opts.TargetBranch}, old: types.Revision(opts.TargetBranch), new: types.WorkTree()}, nil
 
-		// Now diff from that merge base to HEAD (only YOUR changes)
-		args := []string{"-C", repo, "diff", "--name-only", baseCommit, "HEAD"}
-		stdout, err = exec.Command("git", args...).Output()
+	case hasTarget:
+		// Only YOUR changes: diff from where the branch diverged from target
+		baseCommit, err := MergeBase(repo, opts.TargetBranch, "HEAD")
 		if err != nil {
-			return nil, fmt.Errorf("git diff %s HEAD: %w", baseCommit, err)
+			return rangeSpec{}, err
 		}
-	} else {
-		args := []string{"-C", repo, "diff", "--name-only", compareRef}
-		stdout, err = exec.Command("git", args...).Output()
-		if err != nil {
-			return nil, fmt.Errorf("git diff --name-only: %w", err)
+		if opts.IncludeUnstaged {
+			return rangeSpec{args: []string{baseCommit}, old: types.Revision(baseCommit), new: types.WorkTree()}, nil
 		}
+		return rangeSpec{args: []string{baseCommit, "HEAD"}, old: types.Revision(baseCommit), new: types.Revision("HEAD")}, nil
+
+	default:
+		return rangeSpec{args: []string{"HEAD"}, old: types.Revision("HEAD"), new: types.WorkTree()}, nil
 	}
+}
 
-	entries := parseLines(stdout)
-	if includeUnstaged {
-		cachedArgs := []string{"-C", repo, "diff", "--cached", "--name-only"}
-		cached, err := exec.Command("git", cachedArgs...).Output()
-		if err == nil {
-			entries = append(entries, parseLines(cached)...)
-		}
+func setSources(diffs []types.FileDiff, old, new types.ContentSource) {
+	for i := range diffs {
+		diffs[i].OldSource = old
+		diffs[i].NewSource = new
 	}
-	seen := make(map[string]struct{})
-	var unique []string
-	for _, entry := range entries {
-		entry = strings.TrimSpace(entry)
-		if entry == "" {
-			continue
-		}
-		if _, ok := seen[entry]; ok {
+}
+
+// Commits lists the non-merge commits of from..to, oldest first.
+func Commits(repo, from, to string) ([]types.Commit, error) {
+	revRange := from + ".." + rangeEnd(to)
+	// Records are separated by NUL, fields by the unit separator
+	out, err := exec.Command("git", "-C", filepath.Clean(repo), "log", "--reverse", "--no-merges", "-z", "--format=%H%x1f%s%x1f%B", revRange).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", revRange, commandError(err))
+	}
+
+	var commits []types.Commit
+	for _, record := range strings.Split(string(out), "\x00") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
 			continue
 		}
-		seen[entry] = struct{}{}
-		unique = append(unique, entry)
+		commits = append(commits, types.Commit{
+			SHA:     fields[0],
+			Subject: fields[1],
+			Message: strings.TrimSpace(fields[2]),
+		})
 	}
-	return unique, nil
+	return commits, nil
 }
 
-func diffFile(repo, compareRef, path string, includeUnstaged bool, targetBranch string, local bool) (string, error) {
-	var buf bytes.Buffer
-	if includeUnstaged {
-		args := []string{"-C", repo, "diff", "--cached", compareRef, "--", path}
-		if out, err := exec.Command("git", args...).Output(); err == nil {
-			buf.Write(out)
-		}
+// CommitChanges returns the diff introduced by a single commit. Root commits
+// are diffed against the empty tree.
+func CommitChanges(repo string, commit types.Commit) ([]types.FileDiff, error) {
+	args := []string{"-C", filepath.Clean(repo), "show", "--no-color", "--no-ext-diff", "-M", "-C", "--src-prefix=a/", "--dst-prefix=b/", "--format=", commit.SHA}
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s: %w", commit.ShortSHA(), commandError(err))
 	}
 
-	var args []string
-	if local {
-		// Compare working directory to origin/targetBranch
-		originBranch := targetBranch
-		args = []string{"-C", repo, "diff", originBranch, "--", path}
-	} else if targetBranch != "" && targetBranch != "HEAD" {
-		// Find merge base
-		mergeBaseCmd := exec.Command("git", "-C", repo, "merge-base", targetBranch, "HEAD")
-		mergeBase, err := mergeBaseCmd.Output()
-		if err != nil {
-			return "", fmt.Errorf("git merge-base: %w", err)
-		}
-		baseCommit := strings.TrimSpace(string(mergeBase))
+	diffs := diffpkg.ParseUnified(string(out))
+	setSources(diffs, types.Revision(commit.SHA+"^"), types.Revision(commit.SHA))
+	for i := range diffs {
+		c := commit
+		diffs[i].Commit = &c
+	}
+	return diffs, nil
+}
 
-		// Diff from merge base to HEAD
-		args = []string{"-C", repo, "diff", baseCommit, "HEAD", "--", path}
-	} else {
-		args = []string{"-C", repo, "diff", compareRef, "--", path}
+func rangeEnd(to string) string {
+	if to == "" {
+		return "HEAD"
 	}
+	return to
+}
 
-	out, err := exec.Command("git", args...).Output()
+// ResolveCommit returns the full SHA of the commit rev names.
+func ResolveCommit(repo, rev string) (string, error) {
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}").Output()
 	if err != nil {
-		return "", fmt.Errorf("git diff %s: %w", path, err)
+		return "", fmt.Errorf("git rev-parse %s: %w", rev, commandError(err))
 	}
-	buf.Write(out)
-	return buf.String(), nil
+	return strings.TrimSpace(string(out)), nil
 }
 
-func parseLines(input []byte) []string {
-	raw := strings.Split(string(input), "\n")
-	var result []string
-	for _, line := range raw {
-		line = strings.TrimSpace(line)
-		if line != "" {
-			result = append(result, line)
-		}
+// MergeBase returns the commit where head diverged from target.
+func MergeBase(repo, target, head string) (string, error) {
+	out, err := exec.Command("git", "-C", repo, "merge-base", target, head).Output()
+	if err != nil {
+		return "", fmt.Errorf("git merge-base %s %s: %w", target, head, commandError(err))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runDiff runs a single `git diff` with rename/copy detection and splits its
+// output into one FileDiff per file. Prefixes and colors are forced so user
+// configuration cannot change the format.
+func runDiff(repo string, args ...string) ([]types.FileDiff, error) {
+	base := []string{"-C", repo, "diff", "--no-color", "--no-ext-diff", "-M", "-C", "--src-prefix=a/", "--dst-prefix=b/"}
+	out, err := exec.Command("git", append(base, args...)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", strings.Join(args, " "), commandError(err))
 	}
-	return result
+	return diffpkg.ParseUnified(string(out)), nil
 }
 
-func countPrefix(diff string, prefix rune) int {
-	count := 0
-	for _, line := range strings.Split(diff, "\n") {
-		if len(line) > 0 && rune(line[0]) == prefix {
-			count++
+// untrackedFiles synthesizes a full-add diff for every untracked file that is
+// not ignored by .gitignore and that keep accepts. Files over
+// maxUntrackedSize are skipped and binary files are only sniffed.
+func untrackedFiles(repo string, keep func(string) bool) ([]types.FileDiff, error) {
+	out, err := exec.Command("git", "-C", repo, "ls-files", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-files --others: %w", commandError(err))
+	}
+
+	var diffs []types.FileDiff
+	for _, path := range strings.Split(string(out), "\x00") {
+		// Nested repositories are listed as directories
+		if path == "" || strings.HasSuffix(path, "/") || keep != nil && !keep(path) {
+			continue
+		}
+		fullPath := filepath.Join(repo, path)
+		info, err := os.Lstat(fullPath)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxUntrackedSize {
+			continue
+		}
+		content, err := readUntracked(fullPath)
+		if err != nil {
+			continue
 		}
+		d := diffpkg.NewFileDiff(path, content)
+		d.NewSource = types.WorkTree()
+		diffs = append(diffs, d)
 	}
-	return count
+	return diffs, nil
+}
+
+// readUntracked reads a file, or only its first 8000 bytes when they show it
+// is binary, which is all NewFileDiff needs to tell.
+func readUntracked(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, 8000)
+	n, err := io.ReadFull(f, head)
+	head = head[:n]
+	if err == io.EOF || err == io.ErrUnexpectedEOF || bytes.IndexByte(head, 0) >= 0 {
+		return head, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rest, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	return append(head, rest...), nil
+}
+
+// commandError includes git's stderr in the error when available.
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// IsRepository reports whether path is inside a git work tree.
+func IsRepository(path string) bool {
+	out, err := exec.Command("git", "-C", filepath.Clean(path), "rev-parse", "--is-inside-work-tree").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
 }
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// A block must span this many lines and characters to count as moved,
+// similar to the defaults of `git diff --color-moved`.
+const (
+	minMovedLines = 3
+	minMovedChars = 20
+)
+
+type changedLine struct {
+	norm    string // Content without whitespace
+	newLine int    // New-side line number (added lines only)
+}
+
+// changeBlocks returns the runs of consecutive added and removed lines.
+func changeBlocks(hunks []types.Hunk) (added, removed [][]changedLine) {
+	for _, hunk := range hunks {
+		var curAdded, curRemoved []changedLine
+		closeBlocks := func() {
+			if len(curAdded) > 0 {
+				added = append(added, curAdded)
+				curAdded = nil
+			}
+			if len(curRemoved) > 0 {
+				removed = append(removed, curRemoved)
+				curRemoved = nil
+			}
+		}
+
+		for _, line := range hunk.Lines {
+			switch line.Kind {
+			case types.LineAdded:
+				curAdded = append(curAdded, changedLine{norm: stripSpace(line.Content), newLine: line.NewLine})
+			case types.LineRemoved:
+				curRemoved = append(curRemoved, changedLine{norm: stripSpace(line.Content)})
+			default:
+				closeBlocks()
+			}
+		}
+		closeBlocks()
+	}
+	return added, removed
+}
+
+// MarkMoved detects blocks of added lines whose content was removed elsewhere
+// in the change set (in any file) and records them in FileDiff.Moves.
+func MarkMoved(diffs []types.FileDiff) {
+	type source struct {
+		block  []changedLine
+		offset int
+		path   string
+	}
+
+	// Index every removed line by its normalized content
+	index := make(map[string][]source)
+	for _, d := range diffs {
+		_, removed := changeBlocks(d.Hunks)
+		for _, block := range removed {
+			for offset, line := range block {
+				if line.norm == "" {
+					continue
+				}
+				index[line.norm] = append(index[line.norm], source{block: block, offset: offset, path: d.OldPath})
+			}
+		}
+	}
+	if len(index) == 0 {
+		return
+	}
+
+	for i := range diffs {
+		diffs[i].Moves = nil
+		added, _ := changeBlocks(diffs[i].Hunks)
+		for _, block := range added {
+			for start := 0; start < len(block); {
+				best, bestFrom := 0, ""
+				for _, src := range index[block[start].norm] {
+					n := 0
+					for start+n < len(block) && src.offset+n < len(src.block) && block[start+n].norm == src.block[src.offset+n].norm {
+						n++
+					}
+					if n > best {
+						best, bestFrom = n, src.path
+					}
+				}
+
+				if best >= minMovedLines && movedChars(block[start:start+best]) >= minMovedChars {
+					diffs[i].Moves = append(diffs[i].Moves, types.MovedBlock{
+						Start: block[start].newLine,
+						End:   block[start+best-1].newLine,
+						From:  bestFrom,
+					})
+					start += best
+					continue
+				}
+				start++
+			}
+		}
+	}
+}
+
+// IgnoreWhitespace removes hunks whose changes only touch whitespace (including
+// blank lines) and recounts additions and deletions. Files left without any
+// hunk are marked WhitespaceOnly.
+func IgnoreWhitespace(diffs []types.FileDiff) []types.FileDiff {
+	for i, d := range diffs {
+		if len(d.Hunks) == 0 {
+			continue
+		}
+
+		var kept []types.Hunk
+		for _, hunk := range d.Hunks {
+			if !whitespaceOnly(hunk) {
+				kept = append(kept, hunk)
+			}
+		}
+		if len(kept) == len(d.Hunks) {
+			continue
+		}
+
+		var b strings.Builder
+		b.WriteString(diffpkg.Header(d.Diff))
+		additions, deletions := 0, 0
+		for _, hunk := range kept {
+			b.WriteString(hunk.String())
+			a, del := hunk.Count()
+			additions += a
+			deletions += del
+		}
+
+		diffs[i].Diff = b.String()
+		diffs[i].Hunks = kept
+		diffs[i].Additions = additions
+		diffs[i].Deletions = deletions
+		diffs[i].WhitespaceOnly = len(kept) == 0
+	}
+	return diffs
+}
+
+// whitespaceOnly reports whether the removed and added lines of a hunk are
+// identical once whitespace and blank lines are ignored.
+func whitespaceOnly(hunk types.Hunk) bool {
+	var removed, added []string
+	for _, line := range hunk.Lines {
+		norm := stripSpace(line.Content)
+		if norm == "" {
+			continue
+		}
+		switch line.Kind {
+		case types.LineAdded:
+			added = append(added, norm)
+		case types.LineRemoved:
+			removed = append(removed, norm)
+		}
+	}
+	return strings.Join(removed, "\n") == strings.Join(added, "\n")
+}
+
+// stripSpace removes all whitespace from a line, so lines compare like
+// `git diff -w` does.
+func stripSpace(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func movedChars(lines []changedLine) int {
+	count := 0
+	for _, line := range lines {
+		count += utf8.RuneCountInString(line.norm)
+	}
+	return count
+}
@@ -0,0 +1,155 @@
+package hook
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Marker identifies hooks written by golum so uninstall never removes a hook
+// it does not own.
+const Marker = "# golum-managed hook"
+
+// BypassEnv skips the review when set to a non-empty value.
+const BypassEnv = "GOLUM_SKIP"
+
+// Names lists the hooks managed by Install and Uninstall.
+var Names = []string{"pre-commit", "pre-push"}
+
+type Options struct {
+	RepoPath     string
+	Binary       string // golum executable invoked by the hooks
+	RulesPath    string
+	TargetBranch string // Base of new branches reviewed by pre-push
+	FailOn       string
+	Force        bool // Overwrite hooks not written by golum
+}
+
+// Install writes the pre-commit and pre-push hooks and returns their paths.
+func Install(opts Options) ([]string, error) {
+	dir, err := hooksDir(opts.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create hooks directory: %w", err)
+	}
+
+	var written []string
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), Marker) && !opts.Force {
+			return written, fmt.Errorf("%s already exists and was not written by golum (use --force to overwrite)", path)
+		}
+		if err := os.WriteFile(path, []byte(script(name, opts)), 0o755); err != nil {
+			return written, fmt.Errorf("write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Uninstall removes the hooks written by Install and returns their paths.
+func Uninstall(repoPath string) ([]string, error) {
+	dir, err := hooksDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, name := range Names {
+		path := filepath.Join(dir, name)
+		existing, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(existing), Marker) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// hooksDir resolves the hooks directory, honoring core.hooksPath and worktrees.
+func hooksDir(repoPath string) (string, error) {
+	repo := filepath.Clean(repoPath)
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a git repository: %w", repo, err)
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return dir, nil
+}
+
+// script renders a hook. The AI token is never written to disk: the hook relies
+// on the environment (AI_TOKEN / SCW_SECRET_KEY_AI_USER). Only exit code 1
+// (findings over the --fail-on policy) blocks; tool errors and partial reviews
+// are reported but let git continue.
+//
+// pre-push reviews each pushed ref from the commit the remote has to the one
+// being pushed, as git lists them on stdin. Deletions are skipped and a new
+// branch is reviewed from its merge base with the target branch.
+func script(name string, opts Options) string {
+	args := []string{"review", "--format", "compact"}
+	if name == "pre-commit" {
+		args = append(args, "--staged")
+	}
+	if opts.RulesPath != "" {
+		args = append(args, "--rules-file", opts.RulesPath)
+	}
+	if opts.FailOn != "" {
+		args = append(args, "--fail-on", opts.FailOn)
+	}
+
+	command := shellQuote(opts.Binary)
+	for _, arg := range args {
+		command += " " + shellQuote(arg)
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(fmt.Sprintf("%s: %s\n", Marker, name))
+	b.WriteString(fmt.Sprintf("# Set %s=1 to bypass this hook.\n\n", BypassEnv))
+	b.WriteString(fmt.Sprintf("if [ -n \"$%s\" ]; then\n\texit 0\nfi\n\n", BypassEnv))
+	b.WriteString("blocked=0\n")
+	b.WriteString("check() {\n")
+	b.WriteString("\tstatus=$1\n")
+	b.WriteString("\tif [ \"$status\" -eq 1 ]; then\n\t\tblocked=1\n")
+	b.WriteString("\telif [ \"$status\" -ne 0 ]; then\n")
+	b.WriteString("\t\techo \"golum: review did not complete (exit $status), not blocking\" >&2\n")
+	b.WriteString("\tfi\n}\n\n")
+
+	if name == "pre-push" {
+		b.WriteString("while read -r local_ref local_sha remote_ref remote_sha; do\n")
+		b.WriteString("\t# A deletion pushes no commits\n")
+		b.WriteString("\tcase \"$local_sha\" in *[!0]*) ;; *) continue ;; esac\n")
+		b.WriteString("\tcase \"$remote_sha\" in\n")
+		b.WriteString("\t*[!0]*) from=$remote_sha ;;\n")
+		b.WriteString(fmt.Sprintf("\t*) from=$(git merge-base \"$local_sha\" %s 2>/dev/null) || {\n", shellQuote(opts.TargetBranch)))
+		b.WriteString("\t\techo \"golum: $local_ref has no merge base with the target branch, not reviewed\" >&2\n")
+		b.WriteString("\t\tcontinue\n\t} ;;\n")
+		b.WriteString("\tesac\n")
+		b.WriteString("\t" + command + " --from \"$from\" --to \"$local_sha\" </dev/null\n")
+		b.WriteString("\tcheck $?\n")
+		b.WriteString("done\n\n")
+	} else {
+		b.WriteString(command + "\n")
+		b.WriteString("check $?\n\n")
+	}
+
+	b.WriteString("if [ \"$blocked\" -eq 1 ]; then\n")
+	b.WriteString(fmt.Sprintf("\techo \"golum: %s blocked by review findings (set %s=1 to bypass)\" >&2\n", name, BypassEnv))
+	b.WriteString("\texit 1\nfi\n")
+	b.WriteString("exit 0\n")
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,141 @@
+package imports
+
+import (
+	"os/exec"
+	"path"
+	"strings"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// Importers returns the files of a version of the repository that import
+// name from modulePath, directly or through `export ... from`. An empty name
+// stands for any import of the module, e.g. when it was deleted. Go, Python
+// and Rust files are matched by the package or module they import instead.
+func (r *Resolver) Importers(source types.ContentSource, modulePath, name string) []string {
+	l := lang.ForPath(modulePath)
+	if r == nil || l == nil {
+		return nil
+	}
+	if source.Kind == types.ContentHunks {
+		source = types.WorkTree()
+	}
+	if l.Visibility != lang.ExportStatement {
+		return r.packageImporters(source, l, modulePath, name)
+	}
+
+	// Default imports are named by the importer, usually after the file
+	word := name
+	if name == "" || name == "default" {
+		word = strings.TrimSuffix(path.Base(modulePath), path.Ext(modulePath))
+		if word == "index" {
+			word = path.Base(path.Dir(modulePath))
+		}
+	}
+
+	var importers []string
+	for _, file := range r.grep(source, word) {
+		if file != modulePath && esModule(file) && r.imports(source, file, modulePath, name) {
+			importers = append(importers, file)
+		}
+	}
+	return importers
+}
+
+// imports reports whether file imports or re-exports name from modulePath.
+func (r *Resolver) imports(source types.ContentSource, file, modulePath, name string) bool {
+	content, err := diffpkg.ReadContent(r.repoPath, source, file)
+	if err != nil {
+		return false
+	}
+	// Every line counts as changed, so every imported name is used
+	lines := make([]int, strings.Count(content, "\n")+1)
+	for i := range lines {
+		lines[i] = i + 1
+	}
+	for _, imp := range r.parser.UsedImports(content, lines, file) {
+		if r.refersTo(source, file, imp.Source, modulePath) && (name == "" || contains(imp.Names, name)) {
+			return true
+		}
+	}
+
+	exports := r.module(source, file)
+	if exports == nil {
+		return false
+	}
+	for _, re := range exports.ReExports {
+		if !r.refersTo(source, file, re.Source, modulePath) {
+			continue
+		}
+		if name == "" || re.Names == nil && name != "default" {
+			return true
+		}
+		for _, original := range re.Names {
+			if original == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// refersTo reports whether an import specifier of importer names modulePath.
+// Unlike resolve, modulePath need not exist in source, so imports of a
+// deleted file are found.
+func (r *Resolver) refersTo(source types.ContentSource, importer, spec, modulePath string) bool {
+	for _, base := range r.bases(importer, spec) {
+		for _, candidate := range fileCandidates(base) {
+			if candidate == modulePath {
+				return true
+			}
+			if r.exists(source, candidate) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// grep lists the files of a version of the repository containing word.
+func (r *Resolver) grep(source types.ContentSource, word string) []string {
+	args := []string{"-C", r.repoPath, "grep", "-l", "-z", "-w", "-F"}
+	prefix := ""
+	switch source.Kind {
+	case types.ContentIndex:
+		args = append(args, "--cached", "-e", word)
+	case types.ContentRevision:
+		args = append(args, "-e", word, source.Rev)
+		prefix = source.Rev + ":"
+	default:
+		args = append(args, "--untracked", "-e", word)
+	}
+	// git grep exits with 1 when nothing matches
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, strings.TrimPrefix(name, prefix))
+		}
+	}
+	return files
+}
+
+// esModule reports whether a file imports and exports with ES modules.
+func esModule(file string) bool {
+	l := lang.ForPath(file)
+	return l != nil && l.Visibility == lang.ExportStatement
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package imports
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// goModule matches the module directive of a go.mod file.
+var goModule = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// packageImporters is Importers for Go, Python and Rust, whose files import
+// a package or module by its name rather than by the path of a file.
+func (r *Resolver) packageImporters(source types.ContentSource, l *lang.Language, modulePath, name string) []string {
+	target, ok := r.moduleName(source, l, modulePath)
+	// A Go package outlives any one of its files, and methods and impl blocks
+	// are reached through their type
+	if !ok || name == "" && l.Visibility == lang.Capitalized || strings.ContainsAny(name, ". ") {
+		return nil
+	}
+
+	word := name
+	if name == "" {
+		word = target[strings.LastIndexAny(target, ".:")+1:]
+	}
+	crate, _ := rustModule(modulePath)
+	var importers []string
+	for _, file := range r.grep(source, word) {
+		if file == modulePath || lang.ForPath(file) != l {
+			continue
+		}
+		switch l.Visibility {
+		case lang.Capitalized:
+			// Files of the same package use its names without importing it
+			if path.Dir(file) == path.Dir(modulePath) {
+				continue
+			}
+		case lang.PubModifier:
+			if fileCrate, _ := rustModule(file); fileCrate != crate {
+				continue
+			}
+		}
+		if r.references(source, l, file, target, name) {
+			importers = append(importers, file)
+		}
+	}
+	return importers
+}
+
+// references reports whether file uses name from the module named target,
+// or imports the module at all when name is empty.
+func (r *Resolver) references(source types.ContentSource, l *lang.Language, file, target, name string) bool {
+	content, err := diffpkg.ReadContent(r.repoPath, source, file)
+	if err != nil {
+		return false
+	}
+	sep := "::"
+	if l.Visibility == lang.NoUnderscore {
+		sep = "."
+	}
+	for _, imp := range r.parser.ModuleReferences(content, file) {
+		for _, module := range moduleCandidates(l, file, imp.Source) {
+			if name != "" {
+				if sameModule(l, module, target) && (contains(imp.Names, name) || contains(imp.Names, "*")) {
+					return true
+				}
+				continue
+			}
+			if sameModule(l, module, target) {
+				return true
+			}
+			// A module imported from its parent, e.g. `from pkg import mod`
+			for _, n := range imp.Names {
+				if sameModule(l, module+sep+n, target) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// moduleName returns the name other files import a file's package or module
+// by: a Go import path, a dotted Python module or a Rust crate path.
+func (r *Resolver) moduleName(source types.ContentSource, l *lang.Language, file string) (string, bool) {
+	switch l.Visibility {
+	case lang.Capitalized:
+		return r.goImportPath(source, path.Dir(file))
+	case lang.NoUnderscore:
+		module := strings.TrimSuffix(strings.TrimSuffix(file, path.Ext(file)), "/__init__")
+		return strings.ReplaceAll(module, "/", "."), module != "__init__"
+	case lang.PubModifier:
+		crate, module := rustModule(file)
+		return module, crate != ""
+	}
+	return "", false
+}
+
+// goImportPath returns the import path of the package in dir, from the
+// nearest go.mod above it.
+func (r *Resolver) goImportPath(source types.ContentSource, dir string) (string, bool) {
+	for root := dir; ; root = path.Dir(root) {
+		goMod := path.Join(root, "go.mod")
+		if r.exists(source, goMod) {
+			content, err := diffpkg.ReadContent(r.repoPath, source, goMod)
+			if err != nil {
+				return "", false
+			}
+			m := goModule.FindStringSubmatch(content)
+			if m == nil {
+				return "", false
+			}
+			if dir == root {
+				return m[1], true
+			}
+			return m[1] + "/" + strings.TrimPrefix(dir, strings.TrimPrefix(root+"/", "./")), true
+		}
+		if root == "." || root == "/" {
+			return "", false
+		}
+	}
+}
+
+// rustModule returns the source directory of the crate a Rust file belongs
+// to and the path of its module, e.g. "crate::a::b" for src/a/b.rs or
+// src/a/b/mod.rs. The crate is empty for files outside a src directory.
+func rustModule(file string) (crate, module string) {
+	slashed := "/" + file
+	i := strings.LastIndex(slashed, "/src/")
+	if i < 0 {
+		return "", ""
+	}
+	crate = slashed[1 : i+4]
+	rel := strings.TrimSuffix(slashed[i+5:], ".rs")
+	if rel == "lib" || rel == "main" {
+		return crate, "crate"
+	}
+	rel = strings.TrimSuffix(rel, "/mod")
+	return crate, "crate::" + strings.ReplaceAll(rel, "/", "::")
+}
+
+// moduleCandidates returns the absolute names an import of importer may
+// refer to: relative Python imports are resolved against its package and
+// Rust paths against its crate and module.
+func moduleCandidates(l *lang.Language, importer, source string) []string {
+	switch l.Visibility {
+	case lang.NoUnderscore:
+		rest := strings.TrimLeft(source, ".")
+		dots := len(source) - len(rest)
+		if dots == 0 {
+			return []string{source}
+		}
+		pkg := strings.Split(path.Dir(importer), "/")
+		if pkg[0] == "." {
+			pkg = nil
+		}
+		if dots-1 > len(pkg) {
+			return nil
+		}
+		parts := pkg[:len(pkg)-(dots-1)]
+		if rest != "" {
+			parts = append(parts, rest)
+		}
+		return []string{strings.Join(parts, ".")}
+
+	case lang.PubModifier:
+		_, current := rustModule(importer)
+		if current == "" {
+			return nil
+		}
+		segs := strings.Split(source, "::")
+		switch segs[0] {
+		case "crate":
+			return []string{source}
+		case "self":
+			return []string{strings.Join(append([]string{current}, segs[1:]...), "::")}
+		case "super":
+			module := strings.Split(current, "::")
+			for len(segs) > 0 && segs[0] == "super" && len(module) > 1 {
+				module, segs = module[:len(module)-1], segs[1:]
+			}
+			return []string{strings.Join(append(module, segs...), "::")}
+		}
+		// Paths may be relative to the current module or, as in Rust 2015,
+		// to the crate
+		return []string{current + "::" + source, "crate::" + source}
+	}
+	return []string{source}
+}
+
+// sameModule reports whether an imported module is target. Python modules
+// may be imported relative to a source root, e.g. "pkg.mod" for
+// src/pkg/mod.py.
+func sameModule(l *lang.Language, module, target string) bool {
+	if module == target {
+		return true
+	}
+	return l.Visibility == lang.NoUnderscore && strings.HasSuffix(target, "."+module)
+}
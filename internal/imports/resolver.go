@@ -0,0 +1,236 @@
+// Package imports adds the exports of imported modules used by changed code
+// to its review context.
+package imports
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/ai"
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/parser"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// maxReExportDepth bounds how many `export ... from` hops are followed, e.g.
+// through barrel index files.
+const maxReExportDepth = 3
+
+// extensions are tried, in order, after a specifier without a known one.
+var extensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mts", ".cts", ".mjs", ".cjs"}
+
+// Resolver resolves relative and tsconfig-aliased imports of changed files
+// and caches the modules it parses for the whole review.
+type Resolver struct {
+	repoPath string
+	parser   *parser.Parser
+	budget   int // Estimated tokens of imported context per file
+	aliases  aliasSets
+	files    map[types.ContentSource]map[string]bool
+	modules  map[moduleKey]*parser.Exports
+}
+
+type moduleKey struct {
+	source types.ContentSource
+	path   string
+}
+
+func NewResolver(repoPath string, p *parser.Parser, budget int) *Resolver {
+	return &Resolver{
+		repoPath: repoPath,
+		parser:   p,
+		budget:   budget,
+		aliases:  loadPathAliases(repoPath),
+		files:    make(map[types.ContentSource]map[string]bool),
+		modules:  make(map[moduleKey]*parser.Exports),
+	}
+}
+
+// Attach fills ctx.Imported with the exported declarations of other modules
+// that the changed lines of diff use, until the token budget is spent.
+func (r *Resolver) Attach(diff types.FileDiff, ctx *types.CodeContext) {
+	if r == nil || r.budget <= 0 || ctx == nil || len(ctx.ChangedLines) == 0 {
+		return
+	}
+
+	source := diff.NewSource
+	var content string
+	if source.Kind == types.ContentHunks {
+		// Patches only carry hunks; imports are read from the working tree
+		content = diffpkg.ContentFromHunks(diff.Hunks)
+		source = types.WorkTree()
+	} else {
+		var err error
+		if content, err = diffpkg.ReadContent(r.repoPath, source, diff.NewPath); err != nil {
+			return
+		}
+	}
+
+	spent := 0
+	for _, imp := range r.parser.UsedImports(content, ctx.ChangedLines, diff.NewPath) {
+		modulePath, ok := r.resolve(source, diff.NewPath, imp.Source)
+		if !ok {
+			continue
+		}
+		for _, name := range imp.Names {
+			def, ok := r.lookup(source, modulePath, name, 0)
+			if !ok {
+				continue
+			}
+			cost := ai.EstimateTokens(def.Text)
+			if spent+cost > r.budget {
+				continue
+			}
+			spent += cost
+			if name != def.Name {
+				def.Name = fmt.Sprintf("%s (as %s)", def.Name, name)
+			}
+			ctx.Imported = append(ctx.Imported, def)
+		}
+	}
+}
+
+// lookup finds an exported name in a module, following re-exports.
+func (r *Resolver) lookup(source types.ContentSource, modulePath, name string, depth int) (types.Definition, bool) {
+	exports := r.module(source, modulePath)
+	if exports == nil {
+		return types.Definition{}, false
+	}
+	if def, ok := exports.Definitions[name]; ok {
+		def.Path = modulePath
+		return def, true
+	}
+	if depth >= maxReExportDepth {
+		return types.Definition{}, false
+	}
+	for _, re := range exports.ReExports {
+		original := name
+		if re.Names != nil {
+			var ok bool
+			if original, ok = re.Names[name]; !ok {
+				continue
+			}
+		} else if name == "default" {
+			// `export *` does not re-export the default export
+			continue
+		}
+		target, ok := r.resolve(source, modulePath, re.Source)
+		if !ok {
+			continue
+		}
+		if def, ok := r.lookup(source, target, original, depth+1); ok {
+			return def, true
+		}
+	}
+	return types.Definition{}, false
+}
+
+func (r *Resolver) module(source types.ContentSource, modulePath string) *parser.Exports {
+	key := moduleKey{source, modulePath}
+	if exports, ok := r.modules[key]; ok {
+		return exports
+	}
+	var exports *parser.Exports
+	if content, err := diffpkg.ReadContent(r.repoPath, source, modulePath); err == nil {
+		parsed := r.parser.Exports(content, modulePath)
+		exports = &parsed
+	}
+	r.modules[key] = exports
+	return exports
+}
+
+// resolve maps an import specifier of importer to a file of the repository.
+// Package imports that no tsconfig alias covers are not resolved.
+func (r *Resolver) resolve(source types.ContentSource, importer, spec string) (string, bool) {
+	for _, base := range r.bases(importer, spec) {
+		for _, candidate := range fileCandidates(base) {
+			if r.exists(source, candidate) {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// bases returns the paths, without extension, that an import specifier of
+// importer may refer to.
+func (r *Resolver) bases(importer, spec string) []string {
+	var bases []string
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") || spec == "." || spec == ".." {
+		bases = []string{path.Join(path.Dir(importer), spec)}
+	} else {
+		bases = r.aliases.candidates(spec)
+	}
+
+	var inRepo []string
+	for _, base := range bases {
+		if !strings.HasPrefix(base, "../") {
+			inRepo = append(inRepo, base)
+		}
+	}
+	return inRepo
+}
+
+// fileCandidates lists the files a module path may refer to, in the order
+// TypeScript's resolution tries them. An explicit .js extension may name
+// the TypeScript source.
+func fileCandidates(base string) []string {
+	var candidates []string
+	ext := path.Ext(base)
+	switch ext {
+	case ".js", ".jsx", ".mjs", ".cjs":
+		stem := strings.TrimSuffix(base, ext)
+		tsExt := map[string][]string{".js": {".ts", ".tsx"}, ".jsx": {".tsx"}, ".mjs": {".mts"}, ".cjs": {".cts"}}[ext]
+		for _, e := range tsExt {
+			candidates = append(candidates, stem+e)
+		}
+		candidates = append(candidates, base)
+	case ".ts", ".tsx", ".mts", ".cts", ".vue", ".svelte":
+		candidates = append(candidates, base)
+	}
+	for _, e := range extensions {
+		candidates = append(candidates, base+e)
+	}
+	for _, e := range extensions {
+		candidates = append(candidates, path.Join(base, "index"+e))
+	}
+	return candidates
+}
+
+// exists reports whether a file is present in the given version. The file
+// lists of the index and of revisions are loaded once per review.
+func (r *Resolver) exists(source types.ContentSource, file string) bool {
+	if source.Kind == types.ContentWorkTree {
+		info, err := os.Stat(filepath.Join(r.repoPath, filepath.FromSlash(file)))
+		return err == nil && !info.IsDir()
+	}
+
+	files, ok := r.files[source]
+	if !ok {
+		files = listFiles(r.repoPath, source)
+		r.files[source] = files
+	}
+	return files[file]
+}
+
+func listFiles(repoPath string, source types.ContentSource) map[string]bool {
+	args := []string{"-C", repoPath, "ls-files", "-z"}
+	if source.Kind == types.ContentRevision {
+		args = []string{"-C", repoPath, "ls-tree", "-r", "-z", "--name-only", source.Rev}
+	}
+	out, err := exec.Command("git", args...).Output()
+	files := make(map[string]bool)
+	if err != nil {
+		return files
+	}
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files[name] = true
+		}
+	}
+	return files
+}
@@ -0,0 +1,260 @@
+package imports
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tsconfigNames are read from the repository root, first match wins.
+var tsconfigNames = []string{"tsconfig.json", "jsconfig.json"}
+
+// maxExtendsDepth bounds how many `extends` hops are followed.
+const maxExtendsDepth = 5
+
+// pathAliases are the compilerOptions.baseUrl and compilerOptions.paths of a
+// tsconfig, relative to the repository root.
+type pathAliases struct {
+	baseURL  string // Empty when not set
+	paths    map[string][]string
+	pathsDir string // Directory of the config setting paths, which they are relative to without baseUrl
+}
+
+// aliasSets are the path aliases of the root tsconfig and of the projects it
+// references, e.g. tsconfig.app.json.
+type aliasSets []pathAliases
+
+type tsconfig struct {
+	Extends         json.RawMessage `json:"extends"` // A path or package, or a list of them
+	CompilerOptions struct {
+		BaseURL *string             `json:"baseUrl"`
+		Paths   map[string][]string `json:"paths"`
+	} `json:"compilerOptions"`
+	References []struct {
+		Path string `json:"path"`
+	} `json:"references"`
+}
+
+func loadPathAliases(repoPath string) aliasSets {
+	for _, name := range tsconfigNames {
+		if _, err := os.Stat(filepath.Join(repoPath, name)); err != nil {
+			continue
+		}
+		var sets aliasSets
+		loadProject(repoPath, name, make(map[string]bool), &sets)
+		return sets
+	}
+	return nil
+}
+
+// loadProject adds the aliases of a tsconfig and of the projects it
+// references to sets.
+func loadProject(repoPath, file string, seen map[string]bool, sets *aliasSets) {
+	if seen[file] {
+		return
+	}
+	seen[file] = true
+	config, aliases, err := readConfig(repoPath, file, 0)
+	if err != nil {
+		fmt.Printf("⚠️  Ignoring %s for import aliases: %v\n", file, err)
+		return
+	}
+	if aliases.baseURL != "" || aliases.paths != nil {
+		*sets = append(*sets, aliases)
+	}
+	for _, ref := range config.References {
+		referenced := path.Join(path.Dir(file), ref.Path)
+		if path.Ext(referenced) != ".json" {
+			referenced = path.Join(referenced, "tsconfig.json")
+		}
+		loadProject(repoPath, referenced, seen, sets)
+	}
+}
+
+// readConfig parses a tsconfig and returns its aliases merged over those of
+// the configs it extends, which later entries and the file itself override.
+// Configs it cannot extend are skipped with a warning.
+func readConfig(repoPath, file string, depth int) (tsconfig, pathAliases, error) {
+	var config tsconfig
+	content, err := os.ReadFile(filepath.Join(repoPath, filepath.FromSlash(file)))
+	if err != nil {
+		return config, pathAliases{}, err
+	}
+	if err := json.Unmarshal(stripJSONC(content), &config); err != nil {
+		return config, pathAliases{}, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	var aliases pathAliases
+	dir := path.Dir(file)
+	var extends []string
+	if len(config.Extends) > 0 && json.Unmarshal(config.Extends, &extends) != nil {
+		var single string
+		if err := json.Unmarshal(config.Extends, &single); err != nil {
+			return config, pathAliases{}, fmt.Errorf("parse extends of %s: %w", file, err)
+		}
+		extends = []string{single}
+	}
+	for _, spec := range extends {
+		// A missing base config leaves the aliases of the file itself
+		parentFile, ok := resolveExtends(repoPath, dir, spec)
+		if !ok || depth >= maxExtendsDepth {
+			fmt.Printf("⚠️  Could not follow %s extending %s; its aliases are ignored\n", file, spec)
+			continue
+		}
+		_, parent, err := readConfig(repoPath, parentFile, depth+1)
+		if err != nil {
+			fmt.Printf("⚠️  Could not read %s extended by %s: %v\n", parentFile, file, err)
+			continue
+		}
+		if parent.baseURL != "" {
+			aliases.baseURL = parent.baseURL
+		}
+		if parent.paths != nil {
+			aliases.paths, aliases.pathsDir = parent.paths, parent.pathsDir
+		}
+	}
+
+	if config.CompilerOptions.BaseURL != nil {
+		aliases.baseURL = path.Join(dir, *config.CompilerOptions.BaseURL)
+	}
+	if config.CompilerOptions.Paths != nil {
+		aliases.paths, aliases.pathsDir = config.CompilerOptions.Paths, dir
+	}
+	return config, aliases, nil
+}
+
+// resolveExtends finds the config an `extends` entry of a config in dir
+// names: a relative path, or a package installed in node_modules.
+func resolveExtends(repoPath, dir, spec string) (string, bool) {
+	var bases []string
+	if strings.HasPrefix(spec, "./") || strings.HasPrefix(spec, "../") {
+		bases = []string{path.Join(dir, spec)}
+	} else {
+		for d := dir; ; d = path.Dir(d) {
+			bases = append(bases, path.Join(d, "node_modules", spec))
+			if d == "." || d == "/" {
+				break
+			}
+		}
+	}
+	for _, base := range bases {
+		for _, candidate := range []string{base, base + ".json", path.Join(base, "tsconfig.json")} {
+			if strings.HasPrefix(candidate, "../") {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(repoPath, filepath.FromSlash(candidate))); err == nil && !info.IsDir() {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// candidates returns the candidates of every alias set, without duplicates.
+func (s aliasSets) candidates(spec string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, aliases := range s {
+		for _, candidate := range aliases.candidates(spec) {
+			if !seen[candidate] {
+				seen[candidate] = true
+				result = append(result, candidate)
+			}
+		}
+	}
+	return result
+}
+
+// candidates maps a bare specifier to paths relative to the repository root,
+// following paths patterns (a single "*" wildcard each) and then baseUrl.
+func (a pathAliases) candidates(spec string) []string {
+	root := a.baseURL
+	if root == "" {
+		root = a.pathsDir
+	}
+
+	// Like TypeScript, try the pattern with the longest prefix first
+	patterns := make([]string, 0, len(a.paths))
+	for pattern := range a.paths {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		pi, _, _ := strings.Cut(patterns[i], "*")
+		pj, _, _ := strings.Cut(patterns[j], "*")
+		if len(pi) != len(pj) {
+			return len(pi) > len(pj)
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	var result []string
+	for _, pattern := range patterns {
+		targets := a.paths[pattern]
+		prefix, suffix, wildcard := strings.Cut(pattern, "*")
+		var match string
+		switch {
+		case !wildcard && spec == pattern:
+		case wildcard && strings.HasPrefix(spec, prefix) && strings.HasSuffix(spec, suffix) && len(spec) >= len(prefix)+len(suffix):
+			match = spec[len(prefix) : len(spec)-len(suffix)]
+		default:
+			continue
+		}
+		for _, target := range targets {
+			result = append(result, path.Join(root, strings.Replace(target, "*", match, 1)))
+		}
+	}
+	if a.baseURL != "" {
+		result = append(result, path.Join(a.baseURL, spec))
+	}
+	return result
+}
+
+// stripJSONC removes the comments and trailing commas tsconfig files allow.
+func stripJSONC(content []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				out = append(out, content[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			out = append(out, '\n')
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			i += 2
+			for i+1 < len(content) && !(content[i] == '*' && content[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ']' || c == '}':
+			// Drop a trailing comma before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package imports
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"line comment", "{\"a\": 1 // one\n}", "{\"a\": 1 \n}"},
+		{"block comment", `{/* x */"a": 1}`, `{"a": 1}`},
+		{"trailing comma in object", `{"a": 1,}`, `{"a": 1}`},
+		{"trailing comma in array", "{\"a\": [1, 2,\n]}", "{\"a\": [1, 2\n]}"},
+		{"trailing comma before comment", "{\"a\": 1, // last\n}", "{\"a\": 1 \n}"},
+		{"slashes in strings", `{"a": "src/*", "b": "//x"}`, `{"a": "src/*", "b": "//x"}`},
+		{"escaped quote", `{"a": "\"//\""}`, `{"a": "\"//\""}`},
+		{"comma in string", `{"a": ",}"}`, `{"a": ",}"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(stripJSONC([]byte(tt.input)))
+			if got != tt.want {
+				t.Errorf("stripJSONC(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if !json.Valid([]byte(got)) {
+				t.Errorf("stripJSONC(%q) = %q, not valid JSON", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestPathAliasesCandidates(t *testing.T) {
+	aliases := pathAliases{
+		baseURL: "web",
+		paths: map[string][]string{
+			"@/*":            {"src/*"},
+			"@/components/*": {"src/ui/*", "src/legacy/*"},
+			"config":         {"src/config/index"},
+		},
+	}
+	tests := []struct {
+		name    string
+		aliases pathAliases
+		spec    string
+		want    []string
+	}{
+		{"longest prefix first", aliases, "@/components/Button", []string{"web/src/ui/Button", "web/src/legacy/Button", "web/src/components/Button", "web/@/components/Button"}},
+		{"wildcard", aliases, "@/utils/date", []string{"web/src/utils/date", "web/@/utils/date"}},
+		{"exact pattern", aliases, "config", []string{"web/src/config/index", "web/config"}},
+		{"baseUrl only", aliases, "lib/format", []string{"web/lib/format"}},
+		{"paths relative to their config without baseUrl", pathAliases{paths: map[string][]string{"~/*": {"./src/*"}}, pathsDir: "apps/site"}, "~/a", []string{"apps/site/src/a"}},
+		{"no match", pathAliases{paths: map[string][]string{"~/*": {"src/*"}}, pathsDir: "."}, "react", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.aliases.candidates(tt.spec); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidates(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPathAliases(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		spec  string
+		want  []string
+	}{
+		{
+			name: "JSONC with trailing commas",
+			files: map[string]string{
+				"tsconfig.json": "{\n  // Aliases\n  \"compilerOptions\": {\n    \"baseUrl\": \".\",\n    \"paths\": { \"@/*\": [\"src/*\",], },\n  },\n}\n",
+			},
+			spec: "@/a",
+			want: []string{"src/a", "@/a"},
+		},
+		{
+			name: "extends a relative config",
+			files: map[string]string{
+				"tsconfig.json":             `{"extends": "./config/tsconfig.base.json"}`,
+				"config/tsconfig.base.json": `{"compilerOptions": {"paths": {"@/*": ["../src/*"]}}}`,
+			},
+			spec: "@/a",
+			want: []string{"src/a"},
+		},
+		{
+			name: "extends a package and overrides its paths",
+			files: map[string]string{
+				"tsconfig.json": `{"extends": ["@acme/tsconfig"], "compilerOptions": {"paths": {"~/*": ["app/*"]}}}`,
+				"node_modules/@acme/tsconfig/tsconfig.json": `{"compilerOptions": {"paths": {"@/*": ["src/*"]}}}`,
+			},
+			spec: "~/a",
+			want: []string{"app/a"},
+		},
+		{
+			name: "project references",
+			files: map[string]string{
+				"tsconfig.json":              `{"files": [], "references": [{"path": "./tsconfig.app.json"}, {"path": "./packages/api"}]}`,
+				"tsconfig.app.json":          `{"compilerOptions": {"paths": {"@/*": ["./src/*"]}}}`,
+				"packages/api/tsconfig.json": `{"compilerOptions": {"paths": {"@/*": ["./lib/*"]}}}`,
+			},
+			spec: "@/a",
+			want: []string{"src/a", "packages/api/lib/a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := t.TempDir()
+			for name, content := range tt.files {
+				file := filepath.Join(repo, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := loadPathAliases(repo).candidates(tt.spec); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("candidates(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+// Package lang is the registry of the languages golum reviews: their file
+// extensions, tree-sitter grammar, the node types that delimit scopes and
+// declarations, comment syntax and default rule files.
+package lang
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Language describes how to parse and present files of one language.
+type Language struct {
+	Name       string   // Label shown in the prompt, e.g. "typescript"
+	Extensions []string // File extensions, with the dot
+
+	grammar func() unsafe.Pointer
+
+	// FunctionKinds are the node types of functions, methods and closures.
+	FunctionKinds map[string]bool
+	// MethodKinds are the function node types that are always methods.
+	// Functions declared directly in a class body are methods as well.
+	MethodKinds map[string]bool
+	// ClassKinds are the node types of classes and class-like blocks
+	// (impl blocks, traits).
+	ClassKinds map[string]bool
+	// DeclarationKinds maps the node types of named top-level declarations
+	// to the kind shown in the prompt.
+	DeclarationKinds map[string]string
+	// VariableKinds are the statements declaring variables through
+	// declarators, and DeclaratorKinds the declarators themselves.
+	VariableKinds   map[string]bool
+	DeclaratorKinds map[string]bool
+	// WrapperKinds maps nodes that wrap a declaration (`export`,
+	// decorators) to the field holding it.
+	WrapperKinds map[string]string
+	// CallKind and ArgumentsKind are the node types of a call and of its
+	// argument list, used to name callbacks after the function they are
+	// passed to.
+	CallKind      string
+	ArgumentsKind string
+	// Components names capitalized functions components and use* functions
+	// hooks, as in React.
+	Components bool
+	// SingleFileComponent marks Vue and Svelte files: their <script> blocks
+	// are parsed with the language of their lang attribute and the rest is
+	// markup. TemplateBlock is set when the markup is a <template> block
+	// rather than everything outside <script> and <style>.
+	SingleFileComponent bool
+	TemplateBlock       bool
+	// Visibility tells which top-level declarations other files can use.
+	Visibility Visibility
+	Comment    Comment
+
+	// RuleFiles are the names of the files of a rules directory that only
+	// apply to this language.
+	RuleFiles []string
+}
+
+// Visibility is how a language marks the declarations of a file that other
+// files can use.
+type Visibility int
+
+const (
+	ExportStatement Visibility = iota // `export` (JavaScript, TypeScript)
+	Capitalized                       // Capitalized names (Go)
+	PubModifier                       // A `pub` modifier (Rust)
+	NoUnderscore                      // Names without a leading underscore (Python)
+)
+
+// Comment is the comment syntax of a language. Fields are empty when the
+// language lacks that kind of comment.
+type Comment struct {
+	Line       string
+	BlockStart string
+	BlockEnd   string
+}
+
+// Blank returns lines with their comments replaced by spaces, keeping the
+// columns of the rest. Comment markers inside strings are not told apart.
+func (c Comment) Blank(lines []string) []string {
+	blanked := make([]string, len(lines))
+	inBlock := false
+	for i, line := range lines {
+		var b strings.Builder
+		for rest := line; rest != ""; {
+			if inBlock {
+				end := strings.Index(rest, c.BlockEnd)
+				if end < 0 {
+					b.WriteString(strings.Repeat(" ", len(rest)))
+					break
+				}
+				b.WriteString(strings.Repeat(" ", end+len(c.BlockEnd)))
+				rest, inBlock = rest[end+len(c.BlockEnd):], false
+				continue
+			}
+			start, block := len(rest), false
+			if c.BlockStart != "" {
+				if j := strings.Index(rest, c.BlockStart); j >= 0 {
+					start, block = j, true
+				}
+			}
+			if c.Line != "" {
+				if j := strings.Index(rest, c.Line); j >= 0 && j < start {
+					start, block = j, false
+				}
+			}
+			b.WriteString(rest[:start])
+			if start == len(rest) {
+				break
+			}
+			if !block {
+				b.WriteString(strings.Repeat(" ", len(rest)-start))
+				break
+			}
+			b.WriteString(strings.Repeat(" ", len(c.BlockStart)))
+			rest, inBlock = rest[start+len(c.BlockStart):], true
+		}
+		blanked[i] = b.String()
+	}
+	return blanked
+}
+
+// Grammar returns the tree-sitter grammar of the language, or nil for a
+// single-file component, whose scripts use the grammar of their language.
+func (l *Language) Grammar() *tree_sitter.Language {
+	if l.grammar == nil {
+		return nil
+	}
+	return tree_sitter.NewLanguage(l.grammar())
+}
+
+var byExtension = func() map[string]*Language {
+	m := make(map[string]*Language)
+	for _, l := range languages {
+		for _, ext := range l.Extensions {
+			m[ext] = l
+		}
+	}
+	return m
+}()
+
+// All returns the registered languages.
+func All() []*Language {
+	return languages
+}
+
+// ForPath returns the language of a file from its extension, or nil when the
+// language is not supported.
+func ForPath(p string) *Language {
+	return byExtension[strings.ToLower(path.Ext(p))]
+}
+
+// ByName returns the language with the given name, or nil.
+func ByName(name string) *Language {
+	for _, l := range languages {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// ForRuleFile returns the languages a rule file applies to, from its name.
+// A file that belongs to no language applies to all of them.
+func ForRuleFile(file string) []*Language {
+	base := strings.ToLower(filepath.Base(file))
+	var matched []*Language
+	for _, l := range languages {
+		for _, name := range l.RuleFiles {
+			if name == base {
+				matched = append(matched, l)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+func set(kinds ...string) map[string]bool {
+	m := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		m[k] = true
+	}
+	return m
+}
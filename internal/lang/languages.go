@@ -0,0 +1,149 @@
+package lang
+
+import (
+	"unsafe"
+
+	tree_sitter_go "github.com/tree-sitter/tree-sitter-go/bindings/go"
+	tree_sitter_javascript "github.com/tree-sitter/tree-sitter-javascript/bindings/go"
+	tree_sitter_python "github.com/tree-sitter/tree-sitter-python/bindings/go"
+	tree_sitter_rust "github.com/tree-sitter/tree-sitter-rust/bindings/go"
+	tree_sitter_typescript "github.com/tree-sitter/tree-sitter-typescript/bindings/go"
+)
+
+var cComments = Comment{Line: "//", BlockStart: "/*", BlockEnd: "*/"}
+
+// JavaScript and TypeScript share their node types; TypeScript only adds
+// declarations.
+var (
+	jsFunctionKinds = set(
+		"function_declaration", "generator_function_declaration", "function_expression",
+		"function", "generator_function", "arrow_function", "method_definition",
+	)
+	jsDeclarationKinds = map[string]string{
+		"function_declaration":           "function",
+		"generator_function_declaration": "function",
+		"class_declaration":              "class",
+	}
+	tsDeclarationKinds = map[string]string{
+		"function_declaration":           "function",
+		"generator_function_declaration": "function",
+		"class_declaration":              "class",
+		"abstract_class_declaration":     "class",
+		"interface_declaration":          "interface",
+		"type_alias_declaration":         "type",
+		"enum_declaration":               "enum",
+	}
+)
+
+func javaScriptFamily(name string, exts []string, grammar func() unsafe.Pointer, decls map[string]string, rules []string) *Language {
+	return &Language{
+		Name:             name,
+		Extensions:       exts,
+		grammar:          grammar,
+		FunctionKinds:    jsFunctionKinds,
+		MethodKinds:      set("method_definition"),
+		ClassKinds:       set("class_declaration", "abstract_class_declaration", "class"),
+		DeclarationKinds: decls,
+		VariableKinds:    set("lexical_declaration", "variable_declaration"),
+		DeclaratorKinds:  set("variable_declarator"),
+		WrapperKinds:     map[string]string{"export_statement": "declaration"},
+		CallKind:         "call_expression",
+		ArgumentsKind:    "arguments",
+		Components:       true,
+		Comment:          cComments,
+		RuleFiles:        rules,
+	}
+}
+
+var languages = []*Language{
+	javaScriptFamily("typescript", []string{".ts", ".mts", ".cts"}, tree_sitter_typescript.LanguageTypescript, tsDeclarationKinds, []string{"typescript.md", "javascript.md"}),
+	javaScriptFamily("tsx", []string{".tsx"}, tree_sitter_typescript.LanguageTSX, tsDeclarationKinds, []string{"typescript.md", "javascript.md", "react.md"}),
+	// The JavaScript grammar includes JSX
+	javaScriptFamily("javascript", []string{".js", ".mjs", ".cjs"}, tree_sitter_javascript.Language, jsDeclarationKinds, []string{"javascript.md"}),
+	javaScriptFamily("jsx", []string{".jsx"}, tree_sitter_javascript.Language, jsDeclarationKinds, []string{"javascript.md", "react.md"}),
+	{
+		Name:          "go",
+		Extensions:    []string{".go"},
+		grammar:       tree_sitter_go.Language,
+		FunctionKinds: set("function_declaration", "method_declaration", "func_literal"),
+		MethodKinds:   set("method_declaration"),
+		ClassKinds:    set(),
+		DeclarationKinds: map[string]string{
+			"function_declaration": "function",
+			"method_declaration":   "method",
+			"type_declaration":     "type",
+		},
+		VariableKinds:   set("const_declaration", "var_declaration"),
+		DeclaratorKinds: set("const_spec", "var_spec"),
+		WrapperKinds:    map[string]string{},
+		CallKind:        "call_expression",
+		ArgumentsKind:   "argument_list",
+		Visibility:      Capitalized,
+		Comment:         cComments,
+		RuleFiles:       []string{"go.md", "golang.md"},
+	},
+	{
+		Name:          "python",
+		Extensions:    []string{".py", ".pyi"},
+		grammar:       tree_sitter_python.Language,
+		FunctionKinds: set("function_definition", "lambda"),
+		MethodKinds:   set(),
+		ClassKinds:    set("class_definition"),
+		DeclarationKinds: map[string]string{
+			"function_definition": "function",
+			"class_definition":    "class",
+		},
+		VariableKinds:   set("expression_statement"),
+		DeclaratorKinds: set("assignment"),
+		WrapperKinds:    map[string]string{"decorated_definition": "definition"},
+		CallKind:        "call",
+		ArgumentsKind:   "argument_list",
+		Visibility:      NoUnderscore,
+		Comment:         Comment{Line: "#"},
+		RuleFiles:       []string{"python.md"},
+	},
+	{
+		Name:          "rust",
+		Extensions:    []string{".rs"},
+		grammar:       tree_sitter_rust.Language,
+		FunctionKinds: set("function_item", "closure_expression"),
+		MethodKinds:   set(),
+		ClassKinds:    set("impl_item", "trait_item"),
+		DeclarationKinds: map[string]string{
+			"function_item":    "function",
+			"struct_item":      "struct",
+			"enum_item":        "enum",
+			"union_item":       "union",
+			"trait_item":       "trait",
+			"impl_item":        "impl",
+			"type_item":        "type",
+			"mod_item":         "module",
+			"macro_definition": "macro",
+			"const_item":       "variable",
+			"static_item":      "variable",
+		},
+		VariableKinds:   set(),
+		DeclaratorKinds: set(),
+		WrapperKinds:    map[string]string{},
+		CallKind:        "call_expression",
+		ArgumentsKind:   "arguments",
+		Visibility:      PubModifier,
+		Comment:         cComments,
+		RuleFiles:       []string{"rust.md"},
+	},
+	{
+		Name:                "vue",
+		Extensions:          []string{".vue"},
+		SingleFileComponent: true,
+		TemplateBlock:       true,
+		Comment:             Comment{BlockStart: "<!--", BlockEnd: "-->"},
+		RuleFiles:           []string{"vue.md", "typescript.md", "javascript.md"},
+	},
+	{
+		Name:                "svelte",
+		Extensions:          []string{".svelte"},
+		SingleFileComponent: true,
+		Comment:             Comment{BlockStart: "<!--", BlockEnd: "-->"},
+		RuleFiles:           []string{"svelte.md", "typescript.md", "javascript.md"},
+	},
+}
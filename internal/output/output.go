@@ -5,15 +5,61 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/lawndlwd/golum/internal/filter"
 	"github.com/lawndlwd/golum/internal/types"
 )
 
 func PrintLocal(comments []types.ReviewComment) {
 	if len(comments) == 0 {
-		fmt.Println("\n✅ All clear! No issues found.\n")
+		fmt.Print("\n✅ All clear! No issues found.\n\n")
 		return
 	}
 
+	fmt.Println("\n" + strings.Repeat("═", 80))
+	fmt.Println("📋 CODE REVIEW RESULTS")
+	fmt.Println(strings.Repeat("═", 80) + "\n")
+
+	files := printFiles(comments)
+
+	fmt.Println(strings.Repeat("═", 80))
+	fmt.Printf("Found %d issue(s) across %d file(s)\n", len(comments), files)
+	fmt.Println(strings.Repeat("═", 80) + "\n")
+}
+
+// PrintByCommit prints the report grouped by commit, in the order of commits.
+func PrintByCommit(commits []types.Commit, comments []types.ReviewComment) {
+	if len(comments) == 0 {
+		fmt.Print("\n✅ All clear! No issues found.\n\n")
+		return
+	}
+
+	byCommit := make(map[string][]types.ReviewComment)
+	for _, c := range comments {
+		byCommit[c.Commit] = append(byCommit[c.Commit], c)
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", 80))
+	fmt.Println("📋 CODE REVIEW RESULTS")
+	fmt.Println(strings.Repeat("═", 80) + "\n")
+
+	files := 0
+	for _, commit := range commits {
+		commitComments := byCommit[commit.SHA]
+		if len(commitComments) == 0 {
+			continue
+		}
+		fmt.Printf("🔖 %s %s\n", commit.ShortSHA(), commit.Subject)
+		fmt.Println(strings.Repeat("━", 80) + "\n")
+		files += printFiles(commitComments)
+	}
+
+	fmt.Println(strings.Repeat("═", 80))
+	fmt.Printf("Found %d issue(s) across %d file(s) in %d commit(s)\n", len(comments), files, len(byCommit))
+	fmt.Println(strings.Repeat("═", 80) + "\n")
+}
+
+// printFiles prints comments grouped by file and returns the number of files.
+func printFiles(comments []types.ReviewComment) int {
 	// Group comments by file
 	byFile := make(map[string][]types.ReviewComment)
 	for _, c := range comments {
@@ -27,14 +73,8 @@ func PrintLocal(comments []types.ReviewComment) {
 	}
 	sort.Strings(files)
 
-	fmt.Println("\n" + strings.Repeat("═", 80))
-	fmt.Println("📋 CODE REVIEW RESULTS")
-	fmt.Println(strings.Repeat("═", 80) + "\n")
-
-	totalIssues := 0
 	for _, file := range files {
 		fileComments := byFile[file]
-		totalIssues += len(fileComments)
 
 		// Print file header
 		fmt.Printf("📄 %s\n", file)
@@ -57,6 +97,14 @@ func PrintLocal(comments []types.ReviewComment) {
 				c.Severity,
 				"\033[0m", // Reset color
 			)
+			if code := strings.TrimSpace(c.Code); code != "" {
+				fmt.Printf("    \033[2m│ %s\033[0m\n", truncate(code, 74))
+			}
+			if c.Unanchored {
+				fmt.Printf("    ⚠️  Not anchored to a changed line: %s\n", c.Note)
+			} else if c.Note != "" {
+				fmt.Printf("    \033[2m(%s)\033[0m\n", c.Note)
+			}
 
 			// Word wrap the comment at 76 chars (80 - 4 for indent)
 			// The comment already contains the severity prefix, so we display it as-is
@@ -68,37 +116,33 @@ func PrintLocal(comments []types.ReviewComment) {
 		}
 	}
 
-	fmt.Println(strings.Repeat("═", 80))
-	fmt.Printf("Found %d issue(s) across %d file(s)\n", totalIssues, len(files))
-	fmt.Println(strings.Repeat("═", 80) + "\n")
+	return len(files)
 }
 
-func getSeverityEmoji(severity string) string {
-	severity = strings.ToLower(severity)
-	switch {
-	case strings.Contains(severity, "blocking"):
+func getSeverityEmoji(severity types.Severity) string {
+	switch severity {
+	case types.SeverityBlocking:
 		return "🚨  "
-	case strings.Contains(severity, "question"):
+	case types.SeverityQuestion:
 		return "❓  "
-	case strings.Contains(severity, "issue"):
+	case types.SeverityIssue:
 		return "⚠️  "
-	case strings.Contains(severity, "suggestion"):
+	case types.SeveritySuggestion:
 		return "💡  "
 	default:
 		return "ℹ️  "
 	}
 }
 
-func getSeverityColor(severity string) string {
-	severity = strings.ToLower(severity)
-	switch {
-	case strings.Contains(severity, "blocking"):
+func getSeverityColor(severity types.Severity) string {
+	switch severity {
+	case types.SeverityBlocking:
 		return "\033[1;31m" // Bold Red
-	case strings.Contains(severity, "question"):
+	case types.SeverityQuestion:
 		return "\033[1;33m" // Bold Yellow
-	case strings.Contains(severity, "issue"):
+	case types.SeverityIssue:
 		return "\033[1;33m" // Bold Yellow
-	case strings.Contains(severity, "suggestion"):
+	case types.SeveritySuggestion:
 		return "\033[1;36m" // Bold Cyan
 	default:
 		return "\033[0m" // Default
@@ -138,7 +182,7 @@ func wordWrap(text string, width int) string {
 	return strings.Join(lines, "\n")
 }
 
-func CountSeverity(comments []types.ReviewComment, severity string) int {
+func CountSeverity(comments []types.ReviewComment, severity types.Severity) int {
 	count := 0
 	for _, c := range comments {
 		if c.Severity == severity {
@@ -147,3 +191,119 @@ func CountSeverity(comments []types.ReviewComment, severity string) int {
 	}
 	return count
 }
+
+// PrintCompact prints one line per comment followed by a severity summary.
+// It is meant for git hooks and CI logs where the boxed report is too noisy.
+func PrintCompact(comments []types.ReviewComment) {
+	// Keep commits in review order, then sort by file and line
+	commitOrder := make(map[string]int)
+	for _, c := range comments {
+		if _, ok := commitOrder[c.Commit]; !ok {
+			commitOrder[c.Commit] = len(commitOrder)
+		}
+	}
+
+	sorted := append([]types.ReviewComment(nil), comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Commit != sorted[j].Commit {
+			return commitOrder[sorted[i].Commit] < commitOrder[sorted[j].Commit]
+		}
+		if sorted[i].FilePath != sorted[j].FilePath {
+			return sorted[i].FilePath < sorted[j].FilePath
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	for _, c := range sorted {
+		if c.Commit != "" {
+			fmt.Printf("%s ", types.Commit{SHA: c.Commit}.ShortSHA())
+		}
+		fmt.Printf("%s:%d: %s%s\033[0m %s\n", c.FilePath, c.Line, getSeverityColor(c.Severity), c.Severity, firstLine(c.Comment))
+	}
+
+	fmt.Printf("golum: %d blocking, %d issue(s), %d suggestion(s), %d question(s)\n",
+		CountSeverity(comments, types.SeverityBlocking),
+		CountSeverity(comments, types.SeverityIssue),
+		CountSeverity(comments, types.SeveritySuggestion),
+		CountSeverity(comments, types.SeverityQuestion),
+	)
+}
+
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		return text[:idx] + " …"
+	}
+	return text
+}
+
+// PrintFilterDecisions explains why each changed file was kept or dropped.
+func PrintFilterDecisions(decisions []filter.Decision) {
+	fmt.Println("🔎 Filter decisions:")
+	for _, d := range decisions {
+		if d.Kept {
+			fmt.Printf("  ✅ %s: %s\n", d.Path, d.Reason)
+		} else {
+			fmt.Printf("  ⛔ %s: %s\n", d.Path, d.Reason)
+		}
+	}
+}
+
+// maxSummarySymbols caps the declarations printed per file.
+const maxSummarySymbols = 15
+
+// PrintSummary prints the declarations each file's change touched, before
+// the review results.
+func PrintSummary(summaries []types.FileSummary) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", 80))
+	fmt.Println("🧬 CHANGE SUMMARY")
+	fmt.Println(strings.Repeat("═", 80) + "\n")
+
+	for _, s := range summaries {
+		header := s.Path
+		if s.Commit != "" {
+			header = types.Commit{SHA: s.Commit}.ShortSHA() + " " + header
+		}
+		if s.Status == types.StatusAdded || s.Status == types.StatusDeleted {
+			header += fmt.Sprintf(" (%s)", s.Status)
+		}
+		fmt.Printf("📄 %s\n", header)
+
+		for i, c := range s.Symbols {
+			if i == maxSummarySymbols {
+				fmt.Printf("  \033[2m… and %d more\033[0m\n", len(s.Symbols)-i)
+				break
+			}
+			marker, color := "~", "\033[2m" // Dim
+			switch c.Change {
+			case types.SymbolAdded:
+				marker, color = "+", "\033[32m" // Green
+			case types.SymbolRemoved:
+				marker, color = "-", "\033[31m" // Red
+			case types.SymbolSignature:
+				marker, color = "±", "\033[33m" // Yellow
+			}
+			fmt.Printf("  %s%s %s\033[0m\n", color, marker, c)
+			if c.Change == types.SymbolSignature {
+				fmt.Printf("      \033[2mbefore: %s\033[0m\n", truncate(c.Before, 70))
+				fmt.Printf("      \033[2mafter:  %s\033[0m\n", truncate(c.After, 70))
+			}
+			if len(c.ImportedBy) > 0 {
+				fmt.Printf("      ⚠️  Still imported by %s\n", strings.Join(c.ImportedBy, ", "))
+			}
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+const (
+	// maxDefinitionLines caps a definition shown in full; larger functions
+	// are shown by their signature and other declarations by their first
+	// lines.
+	maxDefinitionLines = 30
+	// maxDefinitions caps the number of definitions attached to a file.
+	maxDefinitions = 20
+)
+
+// declaration is a top-level declaration of a file. Lines are 1-based and
+// inclusive.
+type declaration struct {
+	name   string
+	kind   string
+	start  int
+	end    int
+	sigEnd int // Last line of the signature of a function; 0 otherwise
+}
+
+// topLevelDeclarations indexes the declarations directly under the program,
+// including exported ones, by name.
+func topLevelDeclarations(l *lang.Language, root *tree_sitter.Node, src []byte) map[string]declaration {
+	decls := make(map[string]declaration)
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		for _, d := range statementDeclarations(l, root.NamedChild(i), src) {
+			decls[d.name] = d
+		}
+	}
+	return decls
+}
+
+// statementDeclarations returns the names declared by a top-level statement,
+// each spanning the whole statement.
+func statementDeclarations(l *lang.Language, stmt *tree_sitter.Node, src []byte) []declaration {
+	node := stmt
+	if field, ok := l.WrapperKinds[stmt.Kind()]; ok {
+		node = stmt.ChildByFieldName(field)
+		if node == nil {
+			return nil
+		}
+	}
+	start, end := int(stmt.StartPosition().Row)+1, int(stmt.EndPosition().Row)+1
+
+	if kind, ok := l.DeclarationKinds[node.Kind()]; ok {
+		if name := nodeName(l, node, src); name != "" {
+			d := declaration{name: name, kind: kind, start: start, end: end}
+			if l.FunctionKinds[node.Kind()] {
+				d.kind = functionScope(l, node, src).kind
+				d.sigEnd = signatureEnd(start, node.ChildByFieldName("body"))
+			}
+			return []declaration{d}
+		}
+		// Grouped declarations, e.g. Go `type ( ... )`
+		var decls []declaration
+		for _, spec := range namedChildren(node) {
+			if name := spec.ChildByFieldName("name"); name != nil {
+				decls = append(decls, declaration{name: name.Utf8Text(src), kind: kind, start: start, end: end})
+			}
+		}
+		return decls
+	}
+
+	if !l.VariableKinds[node.Kind()] {
+		return nil
+	}
+	var decls []declaration
+	for _, declarator := range namedChildren(node) {
+		if !l.DeclaratorKinds[declarator.Kind()] {
+			continue
+		}
+		value := declarator.ChildByFieldName("value")
+		if value == nil {
+			value = declarator.ChildByFieldName("right")
+		}
+		for _, name := range declaratorNames(declarator, src) {
+			d := declaration{name: name, kind: "variable", start: start, end: end}
+			if value != nil && l.FunctionKinds[value.Kind()] {
+				d.kind = functionScope(l, value, src).kind
+				d.sigEnd = signatureEnd(start, value.ChildByFieldName("body"))
+			}
+			decls = append(decls, d)
+		}
+	}
+	return decls
+}
+
+// referencedNames returns the identifiers used on the changed lines, in order
+// of appearance. Only subtrees spanning a changed line are visited.
+func referencedNames(root *tree_sitter.Node, src []byte, changedLines []int) []string {
+	sorted := append([]int(nil), changedLines...)
+	sort.Ints(sorted)
+	spansChange := func(n *tree_sitter.Node) bool {
+		start, end := int(n.StartPosition().Row)+1, int(n.EndPosition().Row)+1
+		i := sort.SearchInts(sorted, start)
+		return i < len(sorted) && sorted[i] <= end
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		if !spansChange(n) {
+			return
+		}
+		switch n.Kind() {
+		case "identifier", "type_identifier", "shorthand_property_identifier":
+			if name := n.Utf8Text(src); !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(root)
+	return names
+}
+
+// usedNames returns the names used on the changed lines: the identifiers of
+// the tree and, in a single-file component, those of the changed markup.
+func usedNames(component *lang.Language, root *tree_sitter.Node, src []byte, lines []string, sections []section, changedLines []int) []string {
+	names := referencedNames(root, src, changedLines)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, name := range markupNames(component, lines, sections, changedLines) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// referencedDefinitions returns the top-level declarations named by the
+// changed lines that the context regions do not already show.
+func referencedDefinitions(l *lang.Language, root *tree_sitter.Node, src []byte, lines []string, names []string, changedLines []int, regions []types.ContextRegion) []types.Definition {
+	decls := topLevelDeclarations(l, root, src)
+	if len(decls) == 0 {
+		return nil
+	}
+
+	shown := func(d declaration) bool {
+		for _, r := range regions {
+			if r.Start <= d.start && d.end <= r.End {
+				return true
+			}
+		}
+		for _, line := range changedLines {
+			if d.start <= line && line <= d.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var defs []types.Definition
+	for _, name := range names {
+		d, ok := decls[name]
+		if !ok || shown(d) {
+			continue
+		}
+		if len(defs) == maxDefinitions {
+			break
+		}
+		defs = append(defs, renderDefinition(lines, d))
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Start < defs[j].Start })
+	return defs
+}
+
+func renderDefinition(lines []string, d declaration) types.Definition {
+	def := types.Definition{Name: d.name, Kind: d.kind, Start: d.start, End: min(d.end, len(lines))}
+	last := def.End
+	if last-d.start+1 > maxDefinitionLines {
+		def.Partial = true
+		last = d.start + maxDefinitionLines - 1
+		if d.sigEnd > 0 {
+			last = d.sigEnd
+		}
+	}
+
+	var text []string
+	for line := d.start; line <= last; line++ {
+		text = append(text, numberedLine(lines, line, false))
+	}
+	if def.Partial {
+		text = append(text, "          ...")
+	}
+	def.Text = strings.Join(text, "\n")
+	return def
+}
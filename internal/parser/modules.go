@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Import is a module imported by a file, with the exported names the changed
+// lines use from it. The default export is named "default".
+type Import struct {
+	Source string // Module specifier as written, e.g. "./api" or "@/hooks"
+	Names  []string
+}
+
+// Exports describes what a module exports.
+type Exports struct {
+	// Definitions maps exported names, "default" included, to their
+	// signature or declaration
+	Definitions map[string]types.Definition
+	ReExports   []ReExport
+}
+
+// ReExport is an `export ... from` statement.
+type ReExport struct {
+	Source string
+	Names  map[string]string // Exported name -> name in Source; nil for `export *`
+}
+
+type importedName struct {
+	source string
+	name   string // Exported name in source, or "*" for a namespace
+}
+
+// UsedImports returns the imports of a file whose bindings are used on the
+// changed lines, in import order. Members of namespace imports are resolved
+// to the exported name they access.
+func (p *Parser) UsedImports(fileContent string, changedLines []int, filename string) []Import {
+	src := []byte(fileContent)
+	tree, _, sections := p.parse(src, filename)
+	if tree == nil {
+		return nil
+	}
+	defer tree.Close()
+	root := tree.RootNode()
+
+	bindings := make(map[string]importedName)
+	var sources []string
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		stmt := root.NamedChild(i)
+		if stmt.Kind() != "import_statement" {
+			continue
+		}
+		source := stringValue(stmt.ChildByFieldName("source"), src)
+		if source == "" {
+			continue
+		}
+		sources = append(sources, source)
+		for _, clause := range namedChildren(stmt) {
+			if clause.Kind() != "import_clause" {
+				continue
+			}
+			for _, binding := range namedChildren(clause) {
+				switch binding.Kind() {
+				case "identifier":
+					bindings[binding.Utf8Text(src)] = importedName{source, "default"}
+				case "namespace_import":
+					for _, id := range namedChildren(binding) {
+						bindings[id.Utf8Text(src)] = importedName{source, "*"}
+					}
+				case "named_imports":
+					for _, spec := range namedChildren(binding) {
+						name := spec.ChildByFieldName("name")
+						if name == nil {
+							continue
+						}
+						local := name
+						if alias := spec.ChildByFieldName("alias"); alias != nil {
+							local = alias
+						}
+						bindings[local.Utf8Text(src)] = importedName{source, name.Utf8Text(src)}
+					}
+				}
+			}
+		}
+	}
+	if len(bindings) == 0 {
+		return nil
+	}
+
+	used := make(map[string][]string)
+	add := func(source, name string) {
+		for _, n := range used[source] {
+			if n == name {
+				return
+			}
+		}
+		used[source] = append(used[source], name)
+	}
+	lines := strings.Split(fileContent, "\n")
+	for _, ref := range usedNames(lang.ForPath(filename), root, src, lines, sections, changedLines) {
+		if b, ok := bindings[ref]; ok && b.name != "*" {
+			add(b.source, b.name)
+		}
+	}
+	for _, access := range memberAccesses(root, src, changedLines) {
+		if b, ok := bindings[access[0]]; ok && b.name == "*" {
+			add(b.source, access[1])
+		}
+	}
+
+	var imports []Import
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if names := used[source]; len(names) > 0 && !seen[source] {
+			seen[source] = true
+			imports = append(imports, Import{Source: source, Names: names})
+		}
+	}
+	return imports
+}
+
+// memberAccesses returns the object.property pairs accessed on the changed
+// lines where the object is a plain identifier.
+func memberAccesses(root *tree_sitter.Node, src []byte, changedLines []int) [][2]string {
+	changed := make(map[int]bool, len(changedLines))
+	for _, line := range changedLines {
+		changed[line] = true
+	}
+
+	var accesses [][2]string
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		if n.Kind() == "member_expression" || n.Kind() == "nested_type_identifier" {
+			object := n.ChildByFieldName("object")
+			if object == nil {
+				object = n.ChildByFieldName("module")
+			}
+			property := n.ChildByFieldName("property")
+			if property == nil {
+				property = n.ChildByFieldName("name")
+			}
+			if object != nil && property != nil && object.Kind() == "identifier" && changed[int(n.StartPosition().Row)+1] {
+				accesses = append(accesses, [2]string{object.Utf8Text(src), property.Utf8Text(src)})
+			}
+		}
+		for _, child := range namedChildren(n) {
+			visit(child)
+		}
+	}
+	visit(root)
+	return accesses
+}
+
+// Exports parses a module and returns its exported declarations, rendered as
+// a signature for functions and classes and in full, up to a cap, otherwise.
+func (p *Parser) Exports(fileContent, filename string) Exports {
+	exports := Exports{Definitions: make(map[string]types.Definition)}
+	src := []byte(fileContent)
+	tree, language, _ := p.parse(src, filename)
+	if tree == nil {
+		return exports
+	}
+	defer tree.Close()
+	root := tree.RootNode()
+	lines := strings.Split(fileContent, "\n")
+	decls := topLevelDeclarations(language, root, src)
+
+	for _, stmt := range namedChildren(root) {
+		if stmt.Kind() != "export_statement" {
+			continue
+		}
+
+		if source := stringValue(stmt.ChildByFieldName("source"), src); source != "" {
+			re := ReExport{Source: source}
+			for _, clause := range namedChildren(stmt) {
+				if clause.Kind() == "export_clause" {
+					re.Names = exportClause(clause, src)
+				}
+			}
+			exports.ReExports = append(exports.ReExports, re)
+			continue
+		}
+
+		isDefault := false
+		for i := uint(0); i < stmt.ChildCount(); i++ {
+			if stmt.Child(i).Kind() == "default" {
+				isDefault = true
+			}
+		}
+
+		if declared := statementDeclarations(language, stmt, src); len(declared) > 0 {
+			for _, d := range declared {
+				exports.Definitions[d.name] = renderSignature(lines, d)
+				if isDefault {
+					exports.Definitions["default"] = renderSignature(lines, d)
+				}
+			}
+			continue
+		}
+
+		if value := stmt.ChildByFieldName("value"); value != nil && isDefault {
+			if d, ok := decls[value.Utf8Text(src)]; ok {
+				exports.Definitions["default"] = renderSignature(lines, d)
+			}
+			continue
+		}
+
+		for _, clause := range namedChildren(stmt) {
+			if clause.Kind() != "export_clause" {
+				continue
+			}
+			for exported, local := range exportClause(clause, src) {
+				if d, ok := decls[local]; ok {
+					exports.Definitions[exported] = renderSignature(lines, d)
+				}
+			}
+		}
+	}
+	return exports
+}
+
+func exportClause(clause *tree_sitter.Node, src []byte) map[string]string {
+	names := make(map[string]string)
+	for _, spec := range namedChildren(clause) {
+		name := spec.ChildByFieldName("name")
+		if name == nil {
+			continue
+		}
+		exported := name
+		if alias := spec.ChildByFieldName("alias"); alias != nil {
+			exported = alias
+		}
+		names[exported.Utf8Text(src)] = name.Utf8Text(src)
+	}
+	return names
+}
+
+// renderSignature shows functions, components and hooks by their signature
+// and other declarations like renderDefinition.
+func renderSignature(lines []string, d declaration) types.Definition {
+	if d.sigEnd == 0 || d.sigEnd >= d.end {
+		return renderDefinition(lines, d)
+	}
+	signature := d
+	signature.end = d.sigEnd
+	def := renderDefinition(lines, signature)
+	def.End = d.end
+	def.Partial = true
+	return def
+}
+
+func stringValue(n *tree_sitter.Node, src []byte) string {
+	if n == nil {
+		return ""
+	}
+	return strings.Trim(n.Utf8Text(src), "\"'`")
+}
+
+func namedChildren(n *tree_sitter.Node) []*tree_sitter.Node {
+	children := make([]*tree_sitter.Node, 0, n.NamedChildCount())
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		children = append(children, n.NamedChild(i))
+	}
+	return children
+}
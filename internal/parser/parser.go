@@ -4,104 +4,148 @@ import (
 	"fmt"
 	"strings"
 
-	tree_sitter "github.com/tree-sitter/go-tree-sitter"
-	tree_sitter_javascript "github.com/tree-sitter/tree-sitter-javascript/bindings/go"
+	"github.com/lawndlwd/golum/internal/lang"
 	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
+// surroundingLines is the number of lines shown before and after a changed
+// line that has no enclosing scope.
+const surroundingLines = 5
+
 type Parser struct {
-	jsParser  *tree_sitter.Parser
-	tsParser  *tree_sitter.Parser
-	tsxParser *tree_sitter.Parser
+	parsers map[*lang.Language]*tree_sitter.Parser
 }
 
 func NewParser() *Parser {
-	return &Parser{
-		jsParser:  tree_sitter.NewParser(),
-		tsParser:  tree_sitter.NewParser(),
-		tsxParser: tree_sitter.NewParser(),
-	}
+	return &Parser{parsers: make(map[*lang.Language]*tree_sitter.Parser)}
 }
 
+// Init loads the grammar of every registered language.
 func (p *Parser) Init() error {
-	lang := tree_sitter.NewLanguage(tree_sitter_javascript.Language())
-
-	p.jsParser.SetLanguage(lang)
-	// For now use the JavaScript grammar for TS/TSX as well
-	p.tsParser.SetLanguage(lang)
-	p.tsxParser.SetLanguage(lang)
+	for _, l := range lang.All() {
+		if l.SingleFileComponent {
+			continue
+		}
+		parser := tree_sitter.NewParser()
+		if err := parser.SetLanguage(l.Grammar()); err != nil {
+			parser.Close()
+			return fmt.Errorf("load %s grammar: %w", l.Name, err)
+		}
+		p.parsers[l] = parser
+	}
 
 	return nil
 }
 
 func (p *Parser) Close() {
-	if p.jsParser != nil {
-		p.jsParser.Close()
+	for _, parser := range p.parsers {
+		parser.Close()
 	}
-	if p.tsParser != nil {
-		p.tsParser.Close()
+}
+
+// parse parses a file with the grammar of its language. The scripts of a
+// single-file component are parsed in place, so positions in the tree are
+// positions in the file, and its sections are returned. The tree is nil when
+// there is no grammar to parse the file with; the caller closes it otherwise.
+func (p *Parser) parse(src []byte, filename string) (*tree_sitter.Tree, *lang.Language, []section) {
+	l := lang.ForPath(filename)
+	if l == nil {
+		return nil, nil, nil
 	}
-	if p.tsxParser != nil {
-		p.tsxParser.Close()
+	if !l.SingleFileComponent {
+		parser := p.parsers[l]
+		if parser == nil {
+			return nil, l, nil
+		}
+		return parser.Parse(src, nil), l, nil
 	}
-}
 
-func (p *Parser) getParserForFile(filename string) *tree_sitter.Parser {
-	switch {
-	case strings.HasSuffix(filename, ".tsx"):
-		return p.tsxParser
-	case strings.HasSuffix(filename, ".ts"):
-		return p.tsParser
-	case strings.HasSuffix(filename, ".jsx"):
-		return p.jsParser
-	case strings.HasSuffix(filename, ".js"):
-		return p.jsParser
-	default:
-		return nil
+	sections := componentSections(l, src)
+	ranges, script := scriptRanges(sections)
+	parser := p.parsers[script]
+	if parser == nil || len(ranges) == 0 {
+		return nil, script, sections
+	}
+	if err := parser.SetIncludedRanges(ranges); err != nil {
+		return nil, script, sections
 	}
+	defer parser.SetIncludedRanges(nil)
+	return parser.Parse(src, nil), script, sections
 }
 
+// AnalyzeCodeContext shows each changed line within its enclosing scope: the
+// function, component, hook, method or top-level statement around it, or for
+// a single-file component the script, template or style block. Lines outside
+// any scope, or in files without a grammar, get a window of surrounding
+// lines. The result is a list of merged, non-overlapping regions.
 func (p *Parser) AnalyzeCodeContext(fileContent string, changedLines []int, filename string) *types.CodeContext {
-	context := &types.CodeContext{
-		ChangedLines: changedLines,
-		Surrounding:  make(map[int]string),
+	context := &types.CodeContext{ChangedLines: changedLines}
+
+	lines := strings.Split(fileContent, "\n")
+	changed := make(map[int]bool, len(changedLines))
+	var spans []span
+
+	src := []byte(fileContent)
+	tree, language, sections := p.parse(src, filename)
+	var root *tree_sitter.Node
+	if tree != nil {
+		defer tree.Close()
+		root = tree.RootNode()
 	}
 
-	parser := p.getParserForFile(filename)
-	if parser == nil {
-		for _, lineNum := range changedLines {
-			context.Surrounding[lineNum] = getSurroundingLines(fileContent, lineNum, 5)
-		}
-		return context
+	type group struct {
+		scope   scope
+		elided  bool
+		changed []int
 	}
-
-	// Always provide simple surrounding context per changed line
+	var groups []*group
+	byRange := make(map[[2]int]*group)
 	for _, lineNum := range changedLines {
-		context.Surrounding[lineNum] = getSurroundingLines(fileContent, lineNum, 5)
+		if lineNum < 1 || lineNum > len(lines) {
+			continue
+		}
+		changed[lineNum] = true
+		var scopes []scope
+		if s := sectionAt(sections, lineNum); s != nil {
+			scopes = append(scopes, s.scope())
+		}
+		if root != nil && (sections == nil || len(scopes) > 0 && scopes[0].kind == "script") {
+			scopes = append(scopes, enclosingScopes(language, root, src, lines, lineNum)...)
+		}
+		if len(scopes) == 0 {
+			spans = append(spans, window(lineNum))
+			continue
+		}
+		s, elided := chooseScope(scopes)
+		key := [2]int{s.start, s.end}
+		if g, ok := byRange[key]; ok {
+			g.changed = append(g.changed, lineNum)
+			continue
+		}
+		g := &group{scope: s, elided: elided, changed: []int{lineNum}}
+		byRange[key] = g
+		groups = append(groups, g)
 	}
 
-	return context
-}
-
-func getSurroundingLines(content string, lineNum, contextLines int) string {
-	lines := strings.Split(content, "\n")
-	start := max(0, lineNum-contextLines-1)
-	end := min(len(lines), lineNum+contextLines)
-
-	if start >= len(lines) || end <= 0 {
-		return ""
+	for _, g := range groups {
+		spans = append(spans, scopeSpans(g.scope, g.elided, g.changed)...)
 	}
-
-	var result []string
-	for i := start; i < end; i++ {
-		prefix := "    "
-		if i == lineNum-1 {
-			prefix = ">>> "
+	context.Regions = renderRegions(lines, mergeSpans(spans), changed)
+	if root != nil {
+		names := usedNames(lang.ForPath(filename), root, src, lines, sections, changedLines)
+		context.Definitions = referencedDefinitions(language, root, src, lines, names, changedLines, context.Regions)
+		if trustedSyntax(sections) {
+			context.SyntaxErrors = syntaxErrors(root, src, changedLines)
 		}
-		result = append(result, fmt.Sprintf("%s%4d: %s", prefix, i+1, lines[i]))
 	}
 
-	return strings.Join(result, "\n")
+	return context
+}
+
+// window is the context of a changed line outside any scope.
+func window(lineNum int) span {
+	return span{start: lineNum - surroundingLines, end: lineNum + surroundingLines}
 }
 
 func max(a, b int) int {
@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// goVersionSuffix matches the major version element of a Go import path.
+var goVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// ModuleReferences returns the modules a Go, Python or Rust file imports,
+// each with the names it uses from them anywhere in the file. Sources are
+// written the language's way: a Go import path, a Python module, possibly
+// relative (".mod"), or a Rust path ("crate::a", "super::b"). The name "*"
+// stands for a wildcard import. ES modules are covered by UsedImports.
+func (p *Parser) ModuleReferences(fileContent, filename string) []Import {
+	src := []byte(fileContent)
+	tree, language, _ := p.parse(src, filename)
+	if tree == nil {
+		return nil
+	}
+	defer tree.Close()
+
+	refs := make(references)
+	root := tree.RootNode()
+	switch language.Visibility {
+	case lang.Capitalized:
+		goReferences(root, src, refs)
+	case lang.NoUnderscore:
+		pythonReferences(root, src, refs)
+	case lang.PubModifier:
+		rustReferences(root, src, refs)
+	default:
+		return nil
+	}
+	return refs.imports()
+}
+
+// references maps module sources to the names used from them.
+type references map[string][]string
+
+func (r references) add(source, name string) {
+	for _, n := range r[source] {
+		if n == name {
+			return
+		}
+	}
+	r[source] = append(r[source], name)
+}
+
+func (r references) imports() []Import {
+	imports := make([]Import, 0, len(r))
+	for source, names := range r {
+		imports = append(imports, Import{Source: source, Names: names})
+	}
+	return imports
+}
+
+// goReferences records the package members a Go file selects, e.g. pkg.Name
+// and pkg.Type.
+func goReferences(root *tree_sitter.Node, src []byte, refs references) {
+	packages := make(map[string]string) // Local package name -> import path
+	walk(root, func(n *tree_sitter.Node) bool {
+		switch n.Kind() {
+		case "import_spec":
+			importPath := stringValue(n.ChildByFieldName("path"), src)
+			local := goPackageName(importPath)
+			if name := n.ChildByFieldName("name"); name != nil {
+				local = name.Utf8Text(src)
+			}
+			if local == "." {
+				refs.add(importPath, "*")
+			} else if local != "_" {
+				packages[local] = importPath
+			}
+			return false
+		case "selector_expression", "qualified_type":
+			pkg, member := n.ChildByFieldName("operand"), n.ChildByFieldName("field")
+			if n.Kind() == "qualified_type" {
+				pkg, member = n.ChildByFieldName("package"), n.ChildByFieldName("name")
+			}
+			if pkg != nil && member != nil {
+				if importPath, ok := packages[pkg.Utf8Text(src)]; ok {
+					refs.add(importPath, member.Utf8Text(src))
+				}
+			}
+		}
+		return true
+	})
+}
+
+// goPackageName guesses the name a package is imported under from its path:
+// the last element apart from a major version, e.g. "yaml" for
+// "gopkg.in/yaml.v3" and "chi" for "github.com/go-chi/chi/v5".
+func goPackageName(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	name := elems[len(elems)-1]
+	if goVersionSuffix.MatchString(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// pythonReferences records the names a Python file imports with `from x
+// import a` and the attributes it reads from modules imported with
+// `import x`.
+func pythonReferences(root *tree_sitter.Node, src []byte, refs references) {
+	modules := make(map[string]string) // Local name -> module
+	for _, stmt := range namedChildren(root) {
+		switch stmt.Kind() {
+		case "import_from_statement":
+			module := stmt.ChildByFieldName("module_name")
+			if module == nil {
+				continue
+			}
+			source := module.Utf8Text(src)
+			for _, child := range namedChildren(stmt) {
+				switch {
+				case child.Kind() == "wildcard_import":
+					refs.add(source, "*")
+				case child.Kind() == "aliased_import":
+					refs.add(source, child.ChildByFieldName("name").Utf8Text(src))
+				case child.Kind() == "dotted_name" && child.StartByte() != module.StartByte():
+					refs.add(source, child.Utf8Text(src))
+				}
+			}
+		case "import_statement":
+			for _, child := range namedChildren(stmt) {
+				local, module := child.Utf8Text(src), child.Utf8Text(src)
+				if child.Kind() == "aliased_import" {
+					local, module = child.ChildByFieldName("alias").Utf8Text(src), child.ChildByFieldName("name").Utf8Text(src)
+				}
+				modules[local] = module
+				if _, ok := refs[module]; !ok {
+					// Imported even if no attribute is read
+					refs[module] = nil
+				}
+			}
+		}
+	}
+	if len(modules) == 0 {
+		return
+	}
+	walk(root, func(n *tree_sitter.Node) bool {
+		if n.Kind() == "attribute" {
+			if module, ok := modules[n.ChildByFieldName("object").Utf8Text(src)]; ok {
+				refs.add(module, n.ChildByFieldName("attribute").Utf8Text(src))
+			}
+		}
+		return true
+	})
+}
+
+// rustReferences records the items a Rust file brings in with `use` and
+// the ones it names by path, e.g. crate::a::b or b::C after `use crate::a::b`.
+func rustReferences(root *tree_sitter.Node, src []byte, refs references) {
+	used := make(map[string]string) // Local name -> full path
+	for _, stmt := range namedChildren(root) {
+		if stmt.Kind() != "use_declaration" {
+			continue
+		}
+		if arg := stmt.ChildByFieldName("argument"); arg != nil {
+			usePaths(arg, "", src, used, refs)
+		}
+	}
+
+	walk(root, func(n *tree_sitter.Node) bool {
+		switch n.Kind() {
+		case "use_declaration":
+			return false
+		case "scoped_identifier", "scoped_type_identifier":
+			scope, name := n.ChildByFieldName("path"), n.ChildByFieldName("name")
+			if scope == nil || name == nil {
+				return true
+			}
+			source := compact(scope.Utf8Text(src))
+			first, rest, _ := strings.Cut(source, "::")
+			if full, ok := used[first]; ok {
+				source = strings.TrimSuffix(full+"::"+rest, "::")
+			}
+			refs.add(source, name.Utf8Text(src))
+		}
+		return true
+	})
+}
+
+// usePaths expands the tree of a `use` declaration into the items it brings
+// into scope, recording each under the path of its parent.
+func usePaths(n *tree_sitter.Node, prefix string, src []byte, used map[string]string, refs references) {
+	bind := func(full, local string) {
+		if i := strings.LastIndex(full, "::"); i >= 0 {
+			refs.add(full[:i], full[i+2:])
+		}
+		used[local] = full
+	}
+
+	switch n.Kind() {
+	case "use_list":
+		for _, child := range namedChildren(n) {
+			usePaths(child, prefix, src, used, refs)
+		}
+	case "scoped_use_list":
+		if scope := n.ChildByFieldName("path"); scope != nil {
+			prefix += compact(scope.Utf8Text(src)) + "::"
+		}
+		if list := n.ChildByFieldName("list"); list != nil {
+			usePaths(list, prefix, src, used, refs)
+		}
+	case "use_as_clause":
+		if scope, alias := n.ChildByFieldName("path"), n.ChildByFieldName("alias"); scope != nil && alias != nil {
+			bind(prefix+compact(scope.Utf8Text(src)), alias.Utf8Text(src))
+		}
+	case "use_wildcard":
+		if scope := n.NamedChild(0); scope != nil {
+			refs.add(prefix+compact(scope.Utf8Text(src)), "*")
+		}
+	case "self":
+		// `use a::{self}` brings in a itself
+		if full := strings.TrimSuffix(prefix, "::"); full != "" {
+			bind(full, path.Base(strings.ReplaceAll(full, "::", "/")))
+		}
+	default:
+		full := prefix + compact(n.Utf8Text(src))
+		bind(full, full[strings.LastIndex(full, ":")+1:])
+	}
+}
+
+// walk visits the nodes of a tree depth first, skipping the children of a
+// node when visit returns false.
+func walk(n *tree_sitter.Node, visit func(*tree_sitter.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for _, child := range namedChildren(n) {
+		walk(child, visit)
+	}
+}
+
+func compact(text string) string {
+	return strings.Join(strings.Fields(text), "")
+}
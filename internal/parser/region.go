@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// span is a range of lines (1-based, inclusive) to show as context, with the
+// title of the scope it covers, if any.
+type span struct {
+	start int
+	end   int
+	scope string
+}
+
+// mergeSpans sorts spans and merges the ones that overlap or touch, so every
+// line is shown at most once.
+func mergeSpans(spans []span) []types.ContextRegion {
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var regions []types.ContextRegion
+	for _, s := range spans {
+		if n := len(regions); n > 0 && s.start <= regions[n-1].End+1 {
+			last := &regions[n-1]
+			last.End = max(last.End, s.end)
+			if s.scope != "" && !contains(last.Scopes, s.scope) {
+				last.Scopes = append(last.Scopes, s.scope)
+			}
+			continue
+		}
+		region := types.ContextRegion{Start: s.start, End: s.end}
+		if s.scope != "" {
+			region.Scopes = []string{s.scope}
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// renderRegions fills the text of sorted, non-overlapping regions in a single
+// pass over the lines of the file. Regions are clamped to the file, and a
+// region made only of changed lines is dropped since the diff already shows it.
+func renderRegions(lines []string, regions []types.ContextRegion, changed map[int]bool) []types.ContextRegion {
+	var rendered []types.ContextRegion
+	for _, region := range regions {
+		region.Start = max(region.Start, 1)
+		region.End = min(region.End, len(lines))
+		if region.Start > region.End {
+			continue
+		}
+
+		var b strings.Builder
+		unchanged := false
+		for line := region.Start; line <= region.End; line++ {
+			if !changed[line] {
+				unchanged = true
+			}
+			if line > region.Start {
+				b.WriteString("\n")
+			}
+			b.WriteString(numberedLine(lines, line, changed[line]))
+		}
+		if !unchanged {
+			continue
+		}
+		region.Text = b.String()
+		rendered = append(rendered, region)
+	}
+	return rendered
+}
+
+// numberedLine formats a line of context, marking changed lines with ">>>".
+func numberedLine(lines []string, line int, changed bool) string {
+	prefix := "    "
+	if changed {
+		prefix = ">>> "
+	}
+	return fmt.Sprintf("%s%4d: %s", prefix, line, lines[line-1])
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// violationCapture names the capture a rule reports; without it, the first
+// capture of the match is reported.
+const violationCapture = "violation"
+
+var placeholder = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// RuleSet is a set of AST rules compiled for the languages they apply to.
+type RuleSet struct {
+	queries map[*lang.Language][]compiledRule
+	count   int
+}
+
+type compiledRule struct {
+	rule  types.ASTRule
+	query *tree_sitter.Query
+}
+
+// defaultRuleLanguages are the languages of a rule that names none:
+// TypeScript and JavaScript, which rules were written for before golum
+// reviewed other languages.
+var defaultRuleLanguages = []string{"typescript", "tsx", "javascript", "jsx"}
+
+// CompileRules compiles the query of every rule for each of its languages. A
+// rule without languages is compiled for the default languages whose
+// grammar accepts the query, so it is an error only when none does.
+func CompileRules(rules []types.ASTRule) (*RuleSet, error) {
+	set := &RuleSet{queries: make(map[*lang.Language][]compiledRule)}
+	for _, rule := range rules {
+		names := rule.Languages
+		explicit := len(names) > 0
+		if !explicit {
+			names = defaultRuleLanguages
+		}
+		targets := make([]*lang.Language, 0, len(names))
+		for _, name := range names {
+			targets = append(targets, lang.ByName(name))
+		}
+
+		compiled := 0
+		var lastErr error
+		for _, l := range targets {
+			grammar := l.Grammar()
+			if grammar == nil {
+				continue
+			}
+			query, qerr := tree_sitter.NewQuery(grammar, rule.Query)
+			if qerr != nil {
+				lastErr = fmt.Errorf("AST rule %s (%s): %s query: %w", rule.Name, rule.Source, l.Name, qerr)
+				if explicit {
+					set.Close()
+					return nil, lastErr
+				}
+				continue
+			}
+			set.queries[l] = append(set.queries[l], compiledRule{rule: rule, query: query})
+			compiled++
+		}
+		if compiled == 0 {
+			set.Close()
+			if lastErr == nil {
+				lastErr = fmt.Errorf("AST rule %s (%s) applies to no language with a grammar", rule.Name, rule.Source)
+			}
+			return nil, lastErr
+		}
+		set.count++
+	}
+	return set, nil
+}
+
+// Len returns the number of rules of the set.
+func (s *RuleSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.count
+}
+
+func (s *RuleSet) Close() {
+	if s == nil {
+		return
+	}
+	for _, rules := range s.queries {
+		for _, r := range rules {
+			r.query.Close()
+		}
+	}
+}
+
+// CheckRules runs the rules that apply to the language of a file and returns
+// a comment for every match starting on a changed line. The scripts of a
+// single-file component are checked with the rules of their language.
+func (p *Parser) CheckRules(set *RuleSet, fileContent string, changedLines []int, filename string) []types.ReviewComment {
+	if set.Len() == 0 {
+		return nil
+	}
+	src := []byte(fileContent)
+	tree, language, _ := p.parse(src, filename)
+	if tree == nil {
+		return nil
+	}
+	defer tree.Close()
+	rules := set.queries[language]
+
+	changed := make(map[int]bool, len(changedLines))
+	for _, line := range changedLines {
+		changed[line] = true
+	}
+
+	type key struct {
+		rule string
+		line int
+	}
+	seen := make(map[key]bool)
+	var comments []types.ReviewComment
+	cursor := tree_sitter.NewQueryCursor()
+	defer cursor.Close()
+	for _, r := range rules {
+		names := r.query.CaptureNames()
+		target, hasTarget := r.query.CaptureIndexForName(violationCapture)
+		matches := cursor.Matches(r.query, tree.RootNode(), src)
+		for match := matches.Next(); match != nil; match = matches.Next() {
+			if len(match.Captures) == 0 {
+				continue
+			}
+			node := match.Captures[0].Node
+			if hasTarget {
+				nodes := match.NodesForCaptureIndex(target)
+				if len(nodes) == 0 {
+					continue
+				}
+				node = nodes[0]
+			}
+			line := int(node.StartPosition().Row) + 1
+			if !changed[line] || seen[key{r.rule.Name, line}] {
+				continue
+			}
+			seen[key{r.rule.Name, line}] = true
+
+			message := placeholder.ReplaceAllStringFunc(r.rule.Message, func(m string) string {
+				name := placeholder.FindStringSubmatch(m)[1]
+				for _, c := range match.Captures {
+					if names[c.Index] == name {
+						return c.Node.Utf8Text(src)
+					}
+				}
+				return m
+			})
+			comments = append(comments, types.ReviewComment{
+				FilePath: filename,
+				Line:     line,
+				Severity: r.rule.Severity,
+				Comment:  fmt.Sprintf("%s: %s", r.rule.Severity, strings.TrimSpace(message)),
+				Note:     "AST rule " + r.rule.Name,
+			})
+		}
+	}
+	return comments
+}
@@ -0,0 +1,265 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+const (
+	// maxScopeLines caps the size of an enclosing scope shown in full. Larger
+	// scopes are shown as their signature plus the lines around the changes.
+	maxScopeLines = 120
+	// maxSignatureLines caps the signature of an elided scope, e.g. a
+	// component with a long destructured props list.
+	maxSignatureLines = 8
+	// elidedContextLines is the number of lines kept around each changed line
+	// of an elided scope.
+	elidedContextLines = 3
+)
+
+// scope is a syntactic unit that encloses changed lines. Lines are 1-based and
+// inclusive.
+type scope struct {
+	kind   string // function, component, hook, method, callback, class-like or statement
+	name   string
+	start  int
+	end    int
+	sigEnd int // Last line of the signature, up to the opening of the body
+}
+
+func (s scope) size() int {
+	return s.end - s.start + 1
+}
+
+// title describes the scope for the prompt. Anonymous top-level statements
+// are not worth a title and return "".
+func (s scope) title() string {
+	if s.kind == "statement" {
+		return ""
+	}
+	if s.name == "" {
+		return fmt.Sprintf("%s (lines %d-%d)", s.kind, s.start, s.end)
+	}
+	return fmt.Sprintf("%s %s (lines %d-%d)", s.kind, s.name, s.start, s.end)
+}
+
+// enclosingScopes returns the scopes containing line, from the top-level
+// statement down to the innermost function.
+func enclosingScopes(l *lang.Language, root *tree_sitter.Node, src []byte, lines []string, line int) []scope {
+	row := uint(line - 1)
+	col := uint(len(lines[line-1]) - len(strings.TrimLeftFunc(lines[line-1], unicode.IsSpace)))
+	point := tree_sitter.Point{Row: row, Column: col}
+	node := root.NamedDescendantForPointRange(point, point)
+	if node == nil {
+		return nil
+	}
+
+	var scopes []scope
+	for n := node; n != nil; n = n.Parent() {
+		parent := n.Parent()
+		if parent == nil {
+			break
+		}
+		switch {
+		case l.FunctionKinds[n.Kind()]:
+			scopes = append(scopes, functionScope(l, n, src))
+		case l.ClassKinds[n.Kind()]:
+			kind := l.DeclarationKinds[n.Kind()]
+			if kind == "" {
+				kind = "class"
+			}
+			scopes = append(scopes, newScope(kind, nodeName(l, n, src), declarationFor(l, n), n.ChildByFieldName("body")))
+		}
+		if parent.Parent() == nil {
+			// Direct child of the program: the top-level statement
+			scopes = append(scopes, newScope("statement", "", n, nil))
+		}
+	}
+
+	// Outermost first, dropping scopes that span the same lines as the one
+	// they enclose (e.g. an exported arrow function and its statement)
+	var result []scope
+	for i := len(scopes) - 1; i >= 0; i-- {
+		s := scopes[i]
+		if len(result) > 0 {
+			last := result[len(result)-1]
+			if last.start == s.start && last.end == s.end {
+				if last.kind == "statement" {
+					result[len(result)-1] = s
+				}
+				continue
+			}
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// functionScope describes a function-like node, widened to the declaration
+// that names it, or to the call it is passed to (e.g. a useEffect callback,
+// so its dependency list is included).
+func functionScope(l *lang.Language, fn *tree_sitter.Node, src []byte) scope {
+	kind, name := "function", nodeName(l, fn, src)
+	if l.MethodKinds[fn.Kind()] || inClassBody(l, fn) {
+		kind = "method"
+	}
+
+	decl := declarationFor(l, fn)
+	if parent := fn.Parent(); parent != nil && parent.Kind() == l.ArgumentsKind {
+		if call := parent.Parent(); call != nil && call.Kind() == l.CallKind {
+			kind, name = "callback", callee(call, src)
+			decl = call
+			if stmt := call.Parent(); stmt != nil && stmt.Kind() == "expression_statement" {
+				decl = stmt
+			}
+		}
+	}
+
+	if l.Components && kind == "function" && name != "" {
+		if isHookName(name) {
+			kind = "hook"
+		} else if unicode.IsUpper([]rune(name)[0]) {
+			kind = "component"
+		}
+	}
+	return newScope(kind, name, decl, fn.ChildByFieldName("body"))
+}
+
+// inClassBody reports whether a function is declared directly in the body of
+// a class, e.g. a Python method or a function of a Rust impl block.
+func inClassBody(l *lang.Language, fn *tree_sitter.Node) bool {
+	n := fn
+	if parent := n.Parent(); parent != nil {
+		if _, ok := l.WrapperKinds[parent.Kind()]; ok {
+			n = parent
+		}
+	}
+	body := n.Parent()
+	if body == nil || body.Parent() == nil {
+		return false
+	}
+	return l.ClassKinds[body.Parent().Kind()]
+}
+
+// declarationFor widens a function or class node to the statement declaring
+// it, including `export`, decorators and `const X =`.
+func declarationFor(l *lang.Language, n *tree_sitter.Node) *tree_sitter.Node {
+	decl := n
+	if parent := decl.Parent(); parent != nil && l.DeclaratorKinds[parent.Kind()] {
+		if stmt := parent.Parent(); stmt != nil {
+			decl = stmt
+		}
+	}
+	if parent := decl.Parent(); parent != nil {
+		if _, ok := l.WrapperKinds[parent.Kind()]; ok {
+			decl = parent
+		}
+	}
+	return decl
+}
+
+func newScope(kind, name string, n, body *tree_sitter.Node) scope {
+	s := scope{
+		kind:  kind,
+		name:  name,
+		start: int(n.StartPosition().Row) + 1,
+		end:   int(n.EndPosition().Row) + 1,
+	}
+	s.sigEnd = signatureEnd(s.start, body)
+	return s
+}
+
+// signatureEnd returns the last line of the signature of a declaration
+// starting at start: the line of the token before its body, so a brace on its
+// own line or a Python colon are handled alike.
+func signatureEnd(start int, body *tree_sitter.Node) int {
+	if body == nil {
+		return start
+	}
+	end := int(body.StartPosition().Row) + 1
+	if prev := body.PrevSibling(); prev != nil {
+		end = int(prev.EndPosition().Row) + 1
+	}
+	return min(max(end, start), start+maxSignatureLines-1)
+}
+
+// nodeName returns the name of a declaration, or of the variable a function
+// is assigned to. A Rust impl block is named after its type.
+func nodeName(l *lang.Language, n *tree_sitter.Node, src []byte) string {
+	if name := n.ChildByFieldName("name"); name != nil {
+		return name.Utf8Text(src)
+	}
+	if parent := n.Parent(); parent != nil && l.DeclaratorKinds[parent.Kind()] {
+		if names := declaratorNames(parent, src); len(names) > 0 {
+			return names[0]
+		}
+	}
+	if _, ok := l.DeclarationKinds[n.Kind()]; ok {
+		if typ := n.ChildByFieldName("type"); typ != nil {
+			return typ.Utf8Text(src)
+		}
+	}
+	return ""
+}
+
+// declaratorNames returns the identifiers a declarator binds: `name` in
+// JavaScript and Go (which allows several), `left` in a Python assignment.
+func declaratorNames(declarator *tree_sitter.Node, src []byte) []string {
+	cursor := declarator.Walk()
+	defer cursor.Close()
+
+	var names []string
+	for _, field := range []string{"name", "left"} {
+		for _, n := range declarator.ChildrenByFieldName(field, cursor) {
+			if n.Kind() == "identifier" {
+				names = append(names, n.Utf8Text(src))
+			}
+		}
+	}
+	return names
+}
+
+func callee(call *tree_sitter.Node, src []byte) string {
+	fn := call.ChildByFieldName("function")
+	if fn == nil {
+		return ""
+	}
+	return fn.Utf8Text(src)
+}
+
+func isHookName(name string) bool {
+	return len(name) > 3 && strings.HasPrefix(name, "use") && unicode.IsUpper(rune(name[3]))
+}
+
+// chooseScope picks the largest enclosing scope that fits maxScopeLines, so a
+// change inside a hook callback shows the whole component when possible. When
+// none fits, the innermost scope is returned with elided set.
+func chooseScope(scopes []scope) (s scope, elided bool) {
+	for _, s := range scopes {
+		if s.size() <= maxScopeLines {
+			return s, false
+		}
+	}
+	return scopes[len(scopes)-1], true
+}
+
+// scopeSpans returns the lines to show for a scope: all of it, or for an
+// elided scope its signature and the lines around each change.
+func scopeSpans(s scope, elided bool, changed []int) []span {
+	if !elided {
+		return []span{{start: s.start, end: s.end, scope: s.title()}}
+	}
+
+	spans := []span{{start: s.start, end: s.sigEnd, scope: s.title()}}
+	for _, line := range changed {
+		spans = append(spans, span{
+			start: max(line-elidedContextLines, s.start),
+			end:   min(line+elidedContextLines, s.end),
+		})
+	}
+	return spans
+}
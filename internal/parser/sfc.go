@@ -0,0 +1,253 @@
+package parser
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// section is a top-level block of a Vue or Svelte single-file component.
+// Lines are 1-based, inclusive, and include the tags.
+type section struct {
+	kind  string         // script, template, style or markup
+	lang  *lang.Language // Language of a script; nil when its lang attribute names none golum parses
+	start int
+	end   int
+	body  tree_sitter.Range // Content between the tags of a script
+}
+
+// scope shows the section as a whole, its opening tag standing for the
+// signature when it has to be elided.
+func (s section) scope() scope {
+	return scope{kind: s.kind, start: s.start, end: s.end, sigEnd: s.start}
+}
+
+// scriptExtensions maps the lang attributes of scripts, e.g. "ts" or
+// "typescript", to the extension of their language.
+var scriptExtensions = map[string]string{
+	"js":         ".js",
+	"javascript": ".js",
+	"jsx":        ".jsx",
+	"ts":         ".ts",
+	"typescript": ".ts",
+	"tsx":        ".tsx",
+}
+
+var (
+	blockTag   = regexp.MustCompile(`(?m)^<(script|template|style)\b([^>]*)>`)
+	langAttr   = regexp.MustCompile(`\blang\s*=\s*["']?([\w-]+)`)
+	identifier = regexp.MustCompile(`[A-Za-z_$][\w$-]*`)
+)
+
+// componentSections splits a single-file component into its top-level
+// blocks, which start at the beginning of a line. Without a template block
+// (Svelte), the lines outside script and style blocks are markup.
+func componentSections(l *lang.Language, src []byte) []section {
+	index := newLineIndex(src)
+	opens := blockTag.FindAllSubmatchIndex(src, -1)
+
+	var sections []section
+	covered := 0
+	for i, m := range opens {
+		if m[0] < covered {
+			// A nested <template> at the start of a line
+			continue
+		}
+		tag := string(src[m[2]:m[3]])
+		closing := []byte("</" + tag + ">")
+		bodyStart, bodyEnd := m[1], len(src)
+		if tag == "template" {
+			// Nested templates close before the block does: take the last
+			// closing tag before the next block
+			next := len(src)
+			for _, o := range opens[i+1:] {
+				if string(src[o[2]:o[3]]) != "template" {
+					next = o[0]
+					break
+				}
+			}
+			if j := bytes.LastIndex(src[bodyStart:next], closing); j >= 0 {
+				bodyEnd = bodyStart + j
+			}
+		} else if j := bytes.Index(src[bodyStart:], closing); j >= 0 {
+			bodyEnd = bodyStart + j
+		}
+		covered = min(bodyEnd+len(closing), len(src))
+
+		s := section{kind: tag, start: index.line(m[0]), end: index.line(max(covered-1, m[0]))}
+		if tag == "script" {
+			s.lang = lang.ForPath(".js")
+			if attr := langAttr.FindSubmatch(src[m[4]:m[5]]); attr != nil {
+				s.lang = lang.ForPath(scriptExtensions[strings.ToLower(string(attr[1]))])
+			}
+			s.body = index.rangeOf(bodyStart, bodyEnd)
+		}
+		sections = append(sections, s)
+	}
+
+	if !l.TemplateBlock {
+		sections = append(sections, markupSections(src, sections)...)
+		sort.Slice(sections, func(i, j int) bool { return sections[i].start < sections[j].start })
+	}
+	return sections
+}
+
+// markupSections returns the runs of non-blank lines between blocks.
+func markupSections(src []byte, blocks []section) []section {
+	lines := strings.Split(string(src), "\n")
+	inBlock := make(map[int]bool)
+	for _, b := range blocks {
+		for line := b.start; line <= b.end; line++ {
+			inBlock[line] = true
+		}
+	}
+
+	var markup []section
+	for line := 1; line <= len(lines); line++ {
+		if inBlock[line] || strings.TrimSpace(lines[line-1]) == "" {
+			continue
+		}
+		if n := len(markup); n > 0 && markup[n-1].end == line-1 {
+			markup[n-1].end = line
+			continue
+		}
+		markup = append(markup, section{kind: "markup", start: line, end: line})
+	}
+	// Blank lines inside markup belong to it
+	for i := 1; i < len(markup); i++ {
+		if !anyInBlock(inBlock, markup[i-1].end+1, markup[i].start-1) {
+			markup[i-1].end = markup[i].end
+			markup = append(markup[:i], markup[i+1:]...)
+			i--
+		}
+	}
+	return markup
+}
+
+func anyInBlock(inBlock map[int]bool, start, end int) bool {
+	for line := start; line <= end; line++ {
+		if inBlock[line] {
+			return true
+		}
+	}
+	return false
+}
+
+// sectionAt returns the section containing line, or nil.
+func sectionAt(sections []section, line int) *section {
+	for i := range sections {
+		if sections[i].start <= line && line <= sections[i].end {
+			return &sections[i]
+		}
+	}
+	return nil
+}
+
+// scriptRanges returns the bodies of the script sections and the language to
+// parse them with: that of the first script, or JavaScript when golum does
+// not parse its language.
+func scriptRanges(sections []section) ([]tree_sitter.Range, *lang.Language) {
+	var ranges []tree_sitter.Range
+	var script *lang.Language
+	for _, s := range sections {
+		if s.kind != "script" {
+			continue
+		}
+		ranges = append(ranges, s.body)
+		if len(ranges) == 1 {
+			script = s.lang
+		}
+	}
+	if script == nil {
+		script = lang.ForPath(".js")
+	}
+	return ranges, script
+}
+
+// trustedSyntax reports whether the parse errors of a file are its own: all
+// the scripts of a single-file component are in the one language they are
+// parsed with, and golum knows it.
+func trustedSyntax(sections []section) bool {
+	var script *lang.Language
+	for _, s := range sections {
+		if s.kind != "script" {
+			continue
+		}
+		if s.lang == nil || script != nil && s.lang != script {
+			return false
+		}
+		script = s.lang
+	}
+	return true
+}
+
+// markupNames returns the identifiers on the changed lines outside scripts,
+// e.g. the bindings and components a template uses, leaving out markup
+// comments. Kebab-case tags are also returned in PascalCase, the name they are
+// imported under.
+func markupNames(l *lang.Language, lines []string, sections []section, changedLines []int) []string {
+	if l == nil || !l.SingleFileComponent {
+		return nil
+	}
+	lines = l.Comment.Blank(lines)
+	var names []string
+	for _, line := range changedLines {
+		s := sectionAt(sections, line)
+		if s == nil || s.kind == "script" || s.kind == "style" || line > len(lines) {
+			continue
+		}
+		for _, name := range identifier.FindAllString(lines[line-1], -1) {
+			if strings.Contains(name, "-") {
+				name = pascalCase(name)
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func pascalCase(kebab string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(kebab, "-") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// lineIndex maps byte offsets of a file to lines and points.
+type lineIndex []int // Offset of the start of each line
+
+func newLineIndex(src []byte) lineIndex {
+	index := lineIndex{0}
+	for i, c := range src {
+		if c == '\n' {
+			index = append(index, i+1)
+		}
+	}
+	return index
+}
+
+// line returns the 1-based line of an offset.
+func (index lineIndex) line(offset int) int {
+	return sort.SearchInts(index, offset+1)
+}
+
+func (index lineIndex) point(offset int) tree_sitter.Point {
+	line := index.line(offset)
+	return tree_sitter.Point{Row: uint(line - 1), Column: uint(offset - index[line-1])}
+}
+
+func (index lineIndex) rangeOf(start, end int) tree_sitter.Range {
+	return tree_sitter.Range{
+		StartByte:  uint(start),
+		EndByte:    uint(end),
+		StartPoint: index.point(start),
+		EndPoint:   index.point(end),
+	}
+}
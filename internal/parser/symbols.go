@@ -0,0 +1,298 @@
+package parser
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/lawndlwd/golum/internal/lang"
+	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// symbol is a top-level declaration as compared between two versions of a
+// file. Texts have their whitespace collapsed.
+type symbol struct {
+	declaration
+	exported  bool
+	signature string // Up to the body of a function or class, the name and type of a variable, or the whole declaration
+	text      string
+}
+
+// ChangedSymbols compares the top-level declarations of the base and new
+// versions of a file. An empty path means the version does not exist: the
+// file was added or deleted. A single-file component is itself a component,
+// named after its file. Nothing is returned when either version does not
+// parse, since its declarations cannot be trusted.
+func (p *Parser) ChangedSymbols(base, basePath, current, currentPath string) []types.SymbolChange {
+	before, ok := p.symbols(base, basePath)
+	if !ok {
+		return nil
+	}
+	after, ok := p.symbols(current, currentPath)
+	if !ok {
+		return nil
+	}
+
+	var changes []types.SymbolChange
+	for key, a := range after {
+		change := types.SymbolChange{Kind: a.kind, Name: key, Exported: a.exported, Line: a.start}
+		b, existed := before[key]
+		switch {
+		case !existed:
+			change.Change = types.SymbolAdded
+		case b.signature != a.signature || b.kind != a.kind:
+			change.Change = types.SymbolSignature
+			change.Before, change.After = b.signature, a.signature
+		case b.text != a.text || b.exported != a.exported:
+			change.Change = types.SymbolModified
+		default:
+			continue
+		}
+		change.WasExported = existed && b.exported
+		changes = append(changes, change)
+	}
+	for key, b := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, types.SymbolChange{
+				Change:      types.SymbolRemoved,
+				Kind:        b.kind,
+				Name:        key,
+				WasExported: b.exported,
+				Line:        b.start,
+			})
+		}
+	}
+
+	if basePath == "" || currentPath == "" {
+		changes = append(changes, fileComponent(basePath, currentPath)...)
+	}
+
+	// The maps are iterated in random order, so every field breaks ties
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		switch {
+		case a.Change != b.Change:
+			return changeOrder[a.Change] < changeOrder[b.Change]
+		case a.Line != b.Line:
+			return a.Line < b.Line
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		}
+		return a.Kind < b.Kind
+	})
+	return changes
+}
+
+// changeOrder lists the changes most likely to break other code first.
+var changeOrder = map[types.SymbolChangeKind]int{
+	types.SymbolRemoved:   0,
+	types.SymbolSignature: 1,
+	types.SymbolAdded:     2,
+	types.SymbolModified:  3,
+}
+
+// fileComponent returns the single-file component an added or deleted file
+// declares, if it is one.
+func fileComponent(basePath, currentPath string) []types.SymbolChange {
+	file := basePath + currentPath
+	if l := lang.ForPath(file); l == nil || !l.SingleFileComponent {
+		return nil
+	}
+	component := types.SymbolChange{
+		Change: types.SymbolAdded,
+		Kind:   "component",
+		Name:   pascalCase(strings.TrimSuffix(path.Base(file), path.Ext(file))),
+		Line:   1,
+	}
+	if currentPath == "" {
+		component.Change, component.WasExported = types.SymbolRemoved, true
+	} else {
+		component.Exported = true
+	}
+	return []types.SymbolChange{component}
+}
+
+// symbols parses one version of a file and indexes its top-level
+// declarations. ok is false when the version does not parse.
+func (p *Parser) symbols(content, filename string) (map[string]symbol, bool) {
+	if filename == "" {
+		return nil, true
+	}
+	src := []byte(content)
+	tree, language, _ := p.parse(src, filename)
+	if tree == nil {
+		return nil, false
+	}
+	defer tree.Close()
+	root := tree.RootNode()
+	if root.HasError() {
+		return nil, false
+	}
+	return fileSymbols(language, root, src), true
+}
+
+// fileSymbols indexes the top-level declarations of a file by name. Go
+// methods are named Type.method and Rust impl blocks after their trait and
+// type, since several share a name.
+func fileSymbols(l *lang.Language, root *tree_sitter.Node, src []byte) map[string]symbol {
+	exported := exportedLocals(l, root, src)
+	symbols := make(map[string]symbol)
+	for _, stmt := range namedChildren(root) {
+		decls := statementDeclarations(l, stmt, src)
+		if len(decls) == 0 {
+			continue
+		}
+		node := stmt
+		if field, ok := l.WrapperKinds[stmt.Kind()]; ok {
+			node = stmt.ChildByFieldName(field)
+		}
+
+		for _, d := range decls {
+			decl, start, end := node, stmt.StartByte(), stmt.EndByte()
+			if l.VariableKinds[node.Kind()] || len(decls) > 1 {
+				decl = declaringChild(l, node, d.name, src)
+			}
+			if len(decls) > 1 {
+				// Grouped declarations are compared one by one
+				start, end = decl.StartByte(), decl.EndByte()
+				d.start, d.end = int(decl.StartPosition().Row)+1, int(decl.EndPosition().Row)+1
+			} else {
+				d.name = qualifiedName(node, d, src)
+			}
+			header := headerEnd(l, decl)
+			if header < start {
+				header = start
+			}
+			symbols[d.name] = symbol{
+				declaration: d,
+				exported:    isExported(l, stmt, node, d.name, exported),
+				signature:   strings.TrimRight(collapse(src[start:header]), " ="),
+				text:        collapse(src[start:end]),
+			}
+		}
+	}
+	return symbols
+}
+
+// declaringChild returns the spec or declarator of a grouped declaration
+// that declares name, or the declaration itself.
+func declaringChild(l *lang.Language, node *tree_sitter.Node, name string, src []byte) *tree_sitter.Node {
+	for _, child := range namedChildren(node) {
+		if l.DeclaratorKinds[child.Kind()] {
+			for _, n := range declaratorNames(child, src) {
+				if n == name {
+					return child
+				}
+			}
+		}
+		if n := child.ChildByFieldName("name"); n != nil && n.Utf8Text(src) == name {
+			return child
+		}
+	}
+	return node
+}
+
+// qualifiedName prefixes a Go method with its receiver type and names a Rust
+// impl block "impl Type" or "impl Trait for Type", apart from the type.
+func qualifiedName(node *tree_sitter.Node, d declaration, src []byte) string {
+	if receiver := node.ChildByFieldName("receiver"); receiver != nil {
+		if typ := firstOfKind(receiver, "type_identifier"); typ != nil {
+			return typ.Utf8Text(src) + "." + d.name
+		}
+	}
+	if d.kind != "impl" {
+		return d.name
+	}
+	if trait := node.ChildByFieldName("trait"); trait != nil {
+		return "impl " + trait.Utf8Text(src) + " for " + d.name
+	}
+	return "impl " + d.name
+}
+
+func firstOfKind(n *tree_sitter.Node, kind string) *tree_sitter.Node {
+	if n.Kind() == kind {
+		return n
+	}
+	for _, child := range namedChildren(n) {
+		if found := firstOfKind(child, kind); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// headerEnd returns the offset where the signature of a declaration ends:
+// the body of a function or class, or the value of a variable. Other
+// declarations, e.g. interfaces and types, are signatures as a whole.
+func headerEnd(l *lang.Language, node *tree_sitter.Node) uint {
+	if l.DeclaratorKinds[node.Kind()] {
+		value := node.ChildByFieldName("value")
+		if value == nil {
+			value = node.ChildByFieldName("right")
+		}
+		if value == nil {
+			return node.EndByte()
+		}
+		if !l.FunctionKinds[value.Kind()] {
+			return value.StartByte()
+		}
+		node = value
+	}
+	if l.FunctionKinds[node.Kind()] || l.ClassKinds[node.Kind()] {
+		if body := node.ChildByFieldName("body"); body != nil {
+			return body.StartByte()
+		}
+	}
+	return node.EndByte()
+}
+
+// exportedLocals returns the names a JavaScript module exports apart from
+// its export declarations: `export { a, b as c }` and `export default a`.
+func exportedLocals(l *lang.Language, root *tree_sitter.Node, src []byte) map[string]bool {
+	names := make(map[string]bool)
+	if l.Visibility != lang.ExportStatement {
+		return names
+	}
+	for _, stmt := range namedChildren(root) {
+		if stmt.Kind() != "export_statement" || stmt.ChildByFieldName("source") != nil {
+			continue
+		}
+		if value := stmt.ChildByFieldName("value"); value != nil && value.Kind() == "identifier" {
+			names[value.Utf8Text(src)] = true
+		}
+		for _, clause := range namedChildren(stmt) {
+			if clause.Kind() == "export_clause" {
+				for _, local := range exportClause(clause, src) {
+					names[local] = true
+				}
+			}
+		}
+	}
+	return names
+}
+
+// isExported reports whether other files can use a top-level declaration.
+func isExported(l *lang.Language, stmt, node *tree_sitter.Node, name string, exported map[string]bool) bool {
+	switch l.Visibility {
+	case lang.Capitalized:
+		name = name[strings.LastIndex(name, ".")+1:]
+		return name != "" && unicode.IsUpper([]rune(name)[0])
+	case lang.PubModifier:
+		for i := uint(0); i < node.ChildCount(); i++ {
+			if node.Child(i).Kind() == "visibility_modifier" {
+				return true
+			}
+		}
+		return false
+	case lang.NoUnderscore:
+		return !strings.HasPrefix(name, "_")
+	default:
+		return stmt.Kind() == "export_statement" || exported[name]
+	}
+}
+
+func collapse(text []byte) string {
+	return strings.Join(strings.Fields(string(text)), " ")
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/types"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// maxErrorText caps the unparsed text quoted in a syntax error.
+const maxErrorText = 40
+
+// SyntaxErrors parses a file and returns its parse errors that overlap the
+// changed lines. Components with scripts in several or unknown languages are
+// not checked, since they are parsed with a single grammar.
+func (p *Parser) SyntaxErrors(fileContent string, changedLines []int, filename string) []types.SyntaxError {
+	src := []byte(fileContent)
+	tree, _, sections := p.parse(src, filename)
+	if tree == nil || !trustedSyntax(sections) {
+		return nil
+	}
+	defer tree.Close()
+	return syntaxErrors(tree.RootNode(), src, changedLines)
+}
+
+// syntaxErrors returns the MISSING nodes and the innermost ERROR nodes of a
+// tree that overlap the changed lines, in file order.
+func syntaxErrors(root *tree_sitter.Node, src []byte, changedLines []int) []types.SyntaxError {
+	if !root.HasError() {
+		return nil
+	}
+	overlaps := func(n *tree_sitter.Node) bool {
+		start, end := int(n.StartPosition().Row)+1, int(n.EndPosition().Row)+1
+		for _, line := range changedLines {
+			if start <= line && line <= end {
+				return true
+			}
+		}
+		return false
+	}
+
+	var errs []types.SyntaxError
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		if !n.HasError() || !overlaps(n) {
+			return
+		}
+		if n.IsMissing() {
+			errs = append(errs, syntaxError(n, src))
+			return
+		}
+		found := len(errs)
+		for i := uint(0); i < n.ChildCount(); i++ {
+			visit(n.Child(i))
+		}
+		if n.IsError() && len(errs) == found {
+			errs = append(errs, syntaxError(n, src))
+		}
+	}
+	visit(root)
+	return errs
+}
+
+func syntaxError(n *tree_sitter.Node, src []byte) types.SyntaxError {
+	start, end := n.StartPosition(), n.EndPosition()
+	e := types.SyntaxError{
+		Line:      int(start.Row) + 1,
+		Column:    int(start.Column) + 1,
+		EndLine:   int(end.Row) + 1,
+		EndColumn: int(end.Column), // Exclusive 0-based is inclusive 1-based
+	}
+	if n.IsMissing() {
+		e.Missing = n.Kind()
+		return e
+	}
+	text, _, _ := strings.Cut(strings.TrimSpace(n.Utf8Text(src)), "\n")
+	if r := []rune(text); len(r) > maxErrorText {
+		text = string(r[:maxErrorText-1]) + "…"
+	}
+	e.Text = text
+	return e
+}
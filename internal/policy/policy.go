@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// Exit codes returned by the CLI so pipelines can tell outcomes apart.
+const (
+	ExitOK            = 0
+	ExitFindings      = 1 // Findings exceed the --fail-on policy
+	ExitToolError     = 2 // Configuration, git or startup failure
+	ExitPartialReview = 3 // Some batches could not be reviewed
+)
+
+// Threshold fails the review when at least Count comments have a severity of
+// Level or higher.
+type Threshold struct {
+	Level types.Severity
+	Count int
+}
+
+// Policy decides whether a set of comments should fail the run. An empty
+// policy never fails.
+type Policy struct {
+	Thresholds []Threshold
+}
+
+// Parse reads a --fail-on specification. Accepted forms:
+//
+//	none                  never fail on findings
+//	blocking              fail on any blocking finding
+//	issue                 fail on any issue or blocking finding
+//	issue:3,blocking:1    fail on 3+ issues (or worse) or any blocking finding
+func Parse(spec string) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "none") || strings.EqualFold(spec, "never") {
+		return Policy{}, nil
+	}
+
+	var p Policy
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, countText, hasCount := strings.Cut(part, ":")
+		if !hasCount {
+			name, countText, hasCount = strings.Cut(part, "=")
+		}
+
+		level, ok := types.ParseSeverityLevel(name)
+		if !ok {
+			return Policy{}, fmt.Errorf("invalid --fail-on level %q (expected none, suggestion, issue or blocking)", name)
+		}
+
+		count := 1
+		if hasCount {
+			parsed, err := strconv.Atoi(strings.TrimSpace(countText))
+			if err != nil || parsed < 1 {
+				return Policy{}, fmt.Errorf("invalid --fail-on count %q for %s", countText, name)
+			}
+			count = parsed
+		}
+
+		p.Thresholds = append(p.Thresholds, Threshold{Level: level, Count: count})
+	}
+
+	return p, nil
+}
+
+// Violations returns a description of every threshold exceeded by comments.
+// Comments that could not be anchored to a changed line count too: they are
+// still findings on the change, e.g. a removed export that is still imported.
+func (p Policy) Violations(comments []types.ReviewComment) []string {
+	var violations []string
+	for _, t := range p.Thresholds {
+		count := CountAtLeast(comments, t.Level)
+		if count >= t.Count {
+			violations = append(violations, fmt.Sprintf("%d finding(s) at %s or above (limit %d)", count, t.Level, t.Count))
+		}
+	}
+	return violations
+}
+
+// Failed reports whether comments exceed any threshold of the policy.
+func (p Policy) Failed(comments []types.ReviewComment) bool {
+	return len(p.Violations(comments)) > 0
+}
+
+// CountAtLeast counts comments whose severity is level or more serious.
+func CountAtLeast(comments []types.ReviewComment, level types.Severity) int {
+	count := 0
+	for _, c := range comments {
+		if c.Severity >= level {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,80 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []Threshold
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{spec: "none", want: nil},
+		{spec: " Never ", want: nil},
+		{spec: "blocking", want: []Threshold{{types.SeverityBlocking, 1}}},
+		{spec: "critical", want: []Threshold{{types.SeverityBlocking, 1}}},
+		{spec: "ISSUE", want: []Threshold{{types.SeverityIssue, 1}}},
+		{spec: "suggestion:5", want: []Threshold{{types.SeveritySuggestion, 5}}},
+		{spec: "issue=3", want: []Threshold{{types.SeverityIssue, 3}}},
+		{spec: "issue:3, blocking:1", want: []Threshold{{types.SeverityIssue, 3}, {types.SeverityBlocking, 1}}},
+		{spec: "issue,,", want: []Threshold{{types.SeverityIssue, 1}}},
+		{spec: "major", wantErr: true},
+		{spec: "issue:0", wantErr: true},
+		{spec: "issue:-2", wantErr: true},
+		{spec: "issue:many", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			p, err := Parse(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %+v, want an error", tt.spec, p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(p.Thresholds, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.spec, p.Thresholds, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailed(t *testing.T) {
+	comments := []types.ReviewComment{
+		{Severity: types.SeveritySuggestion},
+		{Severity: types.SeverityIssue},
+		{Severity: types.SeverityIssue, Unanchored: true},
+	}
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{"none", false},
+		{"blocking", false},
+		{"issue", true},
+		{"issue:2", true}, // Unanchored findings count
+		{"issue:3", false},
+		{"suggestion:3", true},
+		{"blocking,issue:3", false},
+		{"blocking,suggestion:2", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			p, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Failed(comments); got != tt.want {
+				t.Errorf("Failed with %q = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// snapWindow is how far (in lines) a comment without usable quoted code may
+// be moved to reach a changed line.
+const snapWindow = 3
+
+// anchorComments checks every comment against the changed lines of the batch.
+// Paths that are not in the batch are fuzzy-matched to a batch file, lines that
+// are not added lines are re-anchored using the quoted code or snapped to the
+// nearest added line. Comments on unknown files are dropped; comments whose
+// line cannot be anchored are kept but flagged.
+func anchorComments(comments []types.ReviewComment, files []types.FileDiff) []types.ReviewComment {
+	byPath := filesByPath(files)
+
+	var kept []types.ReviewComment
+	for _, c := range comments {
+		file, ok := byPath[c.FilePath]
+		if !ok {
+			match, found := matchPath(c.FilePath, files)
+			if !found {
+				fmt.Printf("  ⚠️  Dropped comment on %s:%d: file is not part of this batch\n", c.FilePath, c.Line)
+				continue
+			}
+			addNote(&c, fmt.Sprintf("path corrected from %s", c.FilePath))
+			c.FilePath = match.NewPath
+			file = byPath[match.NewPath]
+		}
+
+		anchorLine(&c, file)
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+func anchorLine(c *types.ReviewComment, file types.FileDiff) {
+	if line, ok := file.Line(c.Line); ok && line.Kind == types.LineAdded {
+		return
+	}
+
+	added := addedLines(file)
+	if len(added) == 0 {
+		c.Unanchored = true
+		addNote(c, "the file has no added lines")
+		return
+	}
+
+	// The quoted code is the most reliable signal
+	if code := normalizeCode(c.Code); code != "" {
+		if line, ok := nearest(added, c.Line, func(l types.DiffLine) bool { return normalizeCode(l.Content) == code }); ok {
+			addNote(c, fmt.Sprintf("re-anchored from line %d using the quoted code", c.Line))
+			c.Line = line.NewLine
+			return
+		}
+		if line, ok := nearest(added, c.Line, func(l types.DiffLine) bool {
+			content := normalizeCode(l.Content)
+			return content != "" && (strings.Contains(content, code) || strings.Contains(code, content))
+		}); ok {
+			addNote(c, fmt.Sprintf("re-anchored from line %d using the quoted code", c.Line))
+			c.Line = line.NewLine
+			return
+		}
+	}
+
+	if line, ok := nearest(added, c.Line, func(l types.DiffLine) bool { return abs(l.NewLine-c.Line) <= snapWindow }); ok {
+		addNote(c, fmt.Sprintf("snapped from line %d to the nearest changed line", c.Line))
+		c.Line = line.NewLine
+		return
+	}
+
+	c.Unanchored = true
+	addNote(c, fmt.Sprintf("line %d is not a changed line", c.Line))
+}
+
+// matchPath finds the batch file a wrong path most likely refers to: same path
+// after normalization, a suffix match, a unique base name, or the closest path
+// by edit distance: at most two edits, or any number with the same base name.
+func matchPath(raw string, files []types.FileDiff) (types.FileDiff, bool) {
+	want := normalizePath(raw)
+	if want == "" {
+		return types.FileDiff{}, false
+	}
+
+	var suffix, base []types.FileDiff
+	for _, f := range files {
+		have := normalizePath(f.NewPath)
+		switch {
+		case have == want:
+			return f, true
+		case strings.HasSuffix(have, "/"+want), strings.HasSuffix(want, "/"+have):
+			suffix = append(suffix, f)
+		case path.Base(have) == path.Base(want):
+			base = append(base, f)
+		}
+	}
+	if len(suffix) == 1 {
+		return suffix[0], true
+	}
+	if len(base) == 1 {
+		return base[0], true
+	}
+
+	// Sibling files differ by a few edits too, so a farther match must keep
+	// the base name
+	best, bestDist := types.FileDiff{}, -1
+	for _, f := range files {
+		have := normalizePath(f.NewPath)
+		dist := levenshtein(have, want)
+		if dist > 2 && path.Base(have) != path.Base(want) {
+			continue
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = f, dist
+		}
+	}
+	return best, bestDist >= 0
+}
+
+func addedLines(file types.FileDiff) []types.DiffLine {
+	var lines []types.DiffLine
+	for _, h := range file.Hunks {
+		for _, l := range h.Lines {
+			if l.Kind == types.LineAdded {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+// nearest returns the line closest to target among those accepted by match.
+func nearest(lines []types.DiffLine, target int, match func(types.DiffLine) bool) (types.DiffLine, bool) {
+	var best types.DiffLine
+	found := false
+	for _, l := range lines {
+		if !match(l) {
+			continue
+		}
+		if !found || abs(l.NewLine-target) < abs(best.NewLine-target) {
+			best, found = l, true
+		}
+	}
+	return best, found
+}
+
+func addNote(c *types.ReviewComment, note string) {
+	if c.Note != "" {
+		c.Note += "; "
+	}
+	c.Note += note
+}
+
+func normalizePath(p string) string {
+	p = strings.TrimSpace(strings.ReplaceAll(p, `\`, "/"))
+	p = strings.TrimPrefix(p, "./")
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return strings.ToLower(strings.TrimPrefix(p, "/"))
+}
+
+func normalizeCode(code string) string {
+	code = strings.TrimSpace(code)
+	code = strings.TrimPrefix(code, "+")
+	return strings.Join(strings.Fields(code), " ")
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,46 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+func TestMatchPath(t *testing.T) {
+	files := []types.FileDiff{
+		{NewPath: "src/features/billing/components/InvoiceList.tsx"},
+		{NewPath: "src/utils/format.ts"},
+		{NewPath: "src/a/index.ts"},
+		{NewPath: "src/b/index.ts"},
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want string // Empty when the comment should be dropped
+	}{
+		{"normalized", "./SRC/utils/format.ts", "src/utils/format.ts"},
+		{"diff prefix", "b/src/utils/format.ts", "src/utils/format.ts"},
+		{"suffix", "utils/format.ts", "src/utils/format.ts"},
+		{"unique base name", "lib/format.ts", "src/utils/format.ts"},
+		{"typo", "src/utils/fromat.ts", "src/utils/format.ts"},
+		{"sibling file", "src/features/billing/components/InvoiceForm.tsx", ""},
+		{"ambiguous base name, closest directory", "src/b2/index.ts", "src/b/index.ts"},
+		{"unrelated", "README.md", ""},
+		{"empty", "  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchPath(tt.raw, files)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("matchPath(%q) = %q, want no match", tt.raw, got.NewPath)
+				}
+				return
+			}
+			if !ok || got.NewPath != tt.want {
+				t.Errorf("matchPath(%q) = %q, %v, want %q", tt.raw, got.NewPath, ok, tt.want)
+			}
+		})
+	}
+}
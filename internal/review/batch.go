@@ -0,0 +1,141 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/lawndlwd/golum/internal/ai"
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/imports"
+	"github.com/lawndlwd/golum/internal/parser"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// unit is a file, or part of a file, ready to be put in a batch.
+type unit struct {
+	diff    types.FileDiff
+	context *types.CodeContext
+	tokens  int // Estimated size of its section of the prompt
+}
+
+// fitFile enriches a file and, while its prompt alone exceeds the input
+// budget, splits it into units of hunks holding proportionally fewer changes.
+// When the rules alone exceed the budget splitting cannot help and the file
+// is kept whole.
+func fitFile(client *ai.Client, p *parser.Parser, resolver *imports.Resolver, best string, diff types.FileDiff, repoPath string, useTreeSitter bool) []unit {
+	budget := client.InputBudget()
+	base := client.PromptOverhead(best, []types.FileDiff{diff})
+	if base >= budget {
+		u := enrich(p, resolver, diff, repoPath, useTreeSitter)
+		u.tokens = client.FileTokens(u.diff, u.context)
+		return []unit{u}
+	}
+
+	// Successive splits share many hunk groups, which are enriched once
+	enriched := make(map[string]unit)
+	limit := diff.Additions + diff.Deletions
+	for {
+		var units []unit
+		largest := 0
+		for _, part := range splitFile(diff, limit) {
+			u, ok := enriched[part.Diff]
+			if !ok {
+				u = enrich(p, resolver, part, repoPath, useTreeSitter)
+				u.tokens = client.FileTokens(u.diff, u.context)
+				enriched[part.Diff] = u
+			}
+			u.diff.Part, u.diff.Parts = part.Part, part.Parts
+			largest = max(largest, base+u.tokens)
+			units = append(units, u)
+		}
+		if largest <= budget || limit <= 1 {
+			return units
+		}
+		limit = min(limit-1, limit*(budget-base)/(largest-base))
+		limit = max(limit, 1)
+	}
+}
+
+func enrich(p *parser.Parser, resolver *imports.Resolver, diff types.FileDiff, repoPath string, useTreeSitter bool) unit {
+	if !useTreeSitter || p == nil {
+		return unit{diff: diff}
+	}
+	enriched, context, err := diffpkg.EnrichDiffWithContext(repoPath, diff, p)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to enrich context of %s: %v\n", diff.NewPath, err)
+		return unit{diff: diff}
+	}
+	resolver.Attach(enriched, context)
+	return unit{diff: enriched, context: context}
+}
+
+// createBatches packs units into batches whose estimated prompt, rules
+// included, fits the input budget of the client. Units are grouped by
+// directory so related changes are reviewed side by side, and a directory
+// that would fit in a batch of its own is not split across two. A batch is
+// estimated as the shared part of the prompt plus the size of each unit.
+func createBatches(client *ai.Client, best string, units []unit) []types.FileBatch {
+	if len(units) == 0 {
+		return nil
+	}
+	budget := client.InputBudget()
+	sort.SliceStable(units, func(i, j int) bool {
+		return path.Dir(units[i].diff.NewPath) < path.Dir(units[j].diff.NewPath)
+	})
+	// Units are all of one commit, or of none
+	overhead := client.PromptOverhead(best, []types.FileDiff{units[0].diff})
+
+	var batches []types.FileBatch
+	var current types.FileBatch
+	for i, u := range units {
+		if len(current.Files) > 0 {
+			full := current.Tokens+u.tokens > budget
+			if !full && startsDirectory(units, i) {
+				group := unitTokens(directoryGroup(units, i))
+				full = current.Tokens+group > budget && overhead+group <= budget
+			}
+			if full {
+				batches = append(batches, current)
+				current = types.FileBatch{}
+			}
+		}
+
+		if len(current.Files) == 0 {
+			current.Tokens = overhead
+		}
+		current.Files = append(current.Files, u.diff)
+		current.Contexts = append(current.Contexts, u.context)
+		current.TotalChanges += u.diff.Additions + u.diff.Deletions
+		current.Tokens += u.tokens
+	}
+
+	// Don't forget the last batch
+	if len(current.Files) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+func unitTokens(units []unit) int {
+	total := 0
+	for _, u := range units {
+		total += u.tokens
+	}
+	return total
+}
+
+func startsDirectory(units []unit, i int) bool {
+	return i == 0 || path.Dir(units[i-1].diff.NewPath) != path.Dir(units[i].diff.NewPath)
+}
+
+// directoryGroup returns the units of the directory starting at index i.
+func directoryGroup(units []unit, i int) []unit {
+	dir := path.Dir(units[i].diff.NewPath)
+	end := i
+	for end < len(units) && path.Dir(units[end].diff.NewPath) == dir {
+		end++
+	}
+	return units[i:end]
+}
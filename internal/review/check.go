@@ -0,0 +1,60 @@
+package review
+
+import (
+	"fmt"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/parser"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// Check runs the local checks on the changed lines of every file: syntax
+// errors and the AST rules. Syntax errors are only reported when the whole
+// file is available, since the gaps between hunks do not parse. Comments on
+// moved code are dropped, as for the model's comments.
+func Check(p *parser.Parser, rules *parser.RuleSet, diffs []types.FileDiff, repoPath string) []types.ReviewComment {
+	var comments []types.ReviewComment
+	for i, diff := range diffs {
+		if diff.Hunks == nil {
+			diffs[i].Hunks = diffpkg.ParseHunks(diff.Diff)
+			diff = diffs[i]
+		}
+		content, err := diffpkg.NewContent(repoPath, diff)
+		if err != nil {
+			fmt.Printf("  ⚠️  Failed to check %s: %v\n", diff.NewPath, err)
+			continue
+		}
+		changedLines := diff.ChangedLines()
+		if diffpkg.HasFullContent(repoPath, diff) {
+			comments = append(comments, syntaxComments(diff.NewPath, p.SyntaxErrors(content, changedLines, diff.NewPath), changedLines)...)
+		}
+		comments = append(comments, p.CheckRules(rules, content, changedLines, diff.NewPath)...)
+	}
+
+	comments = dropMovedComments(comments, diffs)
+	attachCode(comments, diffs)
+	return comments
+}
+
+// syntaxComments turns syntax errors into blocking comments, each on the
+// first changed line of the error.
+func syntaxComments(path string, errs []types.SyntaxError, changedLines []int) []types.ReviewComment {
+	var comments []types.ReviewComment
+	for _, e := range errs {
+		line := e.Line
+		for _, changed := range changedLines {
+			if e.Line <= changed && changed <= e.EndLine {
+				line = changed
+				break
+			}
+		}
+		comments = append(comments, types.ReviewComment{
+			FilePath: path,
+			Line:     line,
+			Severity: types.SeverityBlocking,
+			Comment:  fmt.Sprintf("%s: This does not parse: %s", types.SeverityBlocking, e),
+			Note:     "syntax error",
+		})
+	}
+	return comments
+}
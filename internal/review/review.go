@@ -3,111 +3,175 @@ package review
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/lawndlwd/golum/internal/ai"
-	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/imports"
 	"github.com/lawndlwd/golum/internal/parser"
 	"github.com/lawndlwd/golum/internal/types"
 )
 
-func Review(ctx context.Context, client *ai.Client, p *parser.Parser, best string, diffs []types.FileDiff, repoPath, targetBranch string, useTreeSitter bool) []types.ReviewComment {
-	// Create batches based on total changes
-	batches := createBatches(diffs, 100) // 100 lines per batch
+// Result holds the comments of a review run, the change summary of its files
+// and how many batches failed.
+type Result struct {
+	Comments      []types.ReviewComment
+	Summary       []types.FileSummary
+	Batches       int
+	FailedBatches int
+}
+
+// Add merges the comments, summaries and batch counts of other into r.
+func (r *Result) Add(other Result) {
+	r.Comments = append(r.Comments, other.Comments...)
+	r.Summary = append(r.Summary, other.Summary...)
+	r.Batches += other.Batches
+	r.FailedBatches += other.FailedBatches
+}
+
+// Partial reports whether some batches could not be reviewed.
+func (r Result) Partial() bool {
+	return r.FailedBatches > 0
+}
+
+// Review reviews diffs in batches. resolver adds the exports of imported
+// modules to the context; it may be nil.
+func Review(ctx context.Context, client *ai.Client, p *parser.Parser, resolver *imports.Resolver, best string, diffs []types.FileDiff, repoPath string, useTreeSitter bool) Result {
+	budget := client.InputBudget()
+	if rules := client.PromptTokens(best, nil, nil); rules >= budget {
+		fmt.Printf("⚠️  Rules alone take ~%d of %d prompt tokens; every file will be sent on its own\n", rules, budget)
+	}
+
+	// Enrich every file first so batches can be sized on the real prompt.
+	// Files whose prompt does not fit on its own are reviewed in several
+	// units of hunks.
+	var units []unit
+	for _, diff := range diffs {
+		fileUnits := fitFile(client, p, resolver, best, diff, repoPath, useTreeSitter)
+		if len(fileUnits) > 1 {
+			fmt.Printf("✂️  Split %s (+%d -%d) into %d parts\n", diff.NewPath, diff.Additions, diff.Deletions, len(fileUnits))
+		}
+		units = append(units, fileUnits...)
+	}
+	batches := createBatches(client, best, units)
 
-	fmt.Printf("📦 Created %d batch(es) for review\n\n", len(batches))
+	fmt.Printf("📦 Created %d batch(es) for review (~%d prompt tokens each at most)\n\n", len(batches), budget)
 
-	var comments []types.ReviewComment
+	result := Result{Batches: len(batches)}
 	for batchIdx, batch := range batches {
-		fmt.Printf("🔄 Processing batch %d/%d (%d file(s), %d total changes)\n",
-			batchIdx+1, len(batches), len(batch.Files), batch.TotalChanges)
+		fmt.Printf("🔄 Processing batch %d/%d (%d file(s), %d total changes, ~%d tokens)\n",
+			batchIdx+1, len(batches), len(batch.Files), batch.TotalChanges, batch.Tokens)
 
 		// Review the entire batch at once
-		batchComments := reviewBatch(ctx, client, p, best, batch, repoPath, targetBranch, useTreeSitter)
-		comments = append(comments, batchComments...)
+		batchComments, err := reviewBatch(ctx, client, best, batch)
+		if err != nil {
+			fmt.Printf("  ❌ Batch review failed: %v\n\n", err)
+			result.FailedBatches++
+			continue
+		}
+		result.Comments = append(result.Comments, batchComments...)
 
 		fmt.Printf("  └─ Found %d issue(s) in this batch\n\n", len(batchComments))
 	}
 
-	return comments
+	result.Comments = MergeComments(result.Comments)
+	return result
 }
 
-func createBatches(diffs []types.FileDiff, maxChangesPerBatch int) []types.FileBatch {
-	var batches []types.FileBatch
-	var currentBatch types.FileBatch
-
-	for _, diff := range diffs {
-		fileChanges := diff.Additions + diff.Deletions
-
-		// If this single file exceeds the limit, give it its own batch
-		if fileChanges > maxChangesPerBatch {
-			// Flush current batch if it has files
-			if len(currentBatch.Files) > 0 {
-				batches = append(batches, currentBatch)
-				currentBatch = types.FileBatch{}
-			}
-
-			// Add large file as its own batch
-			batches = append(batches, types.FileBatch{
-				Files:        []types.FileDiff{diff},
-				TotalChanges: fileChanges,
-			})
+// MergeComments puts the comments of split files back together: comments are
+// grouped per file in first-seen order and sorted by line, and a comment
+// repeated by several parts of a file is kept once.
+func MergeComments(comments []types.ReviewComment) []types.ReviewComment {
+	type key struct {
+		path    string
+		line    int
+		comment string
+	}
+	seen := make(map[key]bool)
+	fileOrder := make(map[string]int)
+	var merged []types.ReviewComment
+	for _, c := range comments {
+		k := key{c.FilePath, c.Line, c.Comment}
+		if seen[k] {
 			continue
 		}
+		seen[k] = true
+		if _, ok := fileOrder[c.FilePath]; !ok {
+			fileOrder[c.FilePath] = len(fileOrder)
+		}
+		merged = append(merged, c)
+	}
 
-		// If adding this file would exceed the limit, start a new batch
-		if currentBatch.TotalChanges+fileChanges > maxChangesPerBatch && len(currentBatch.Files) > 0 {
-			batches = append(batches, currentBatch)
-			currentBatch = types.FileBatch{}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].FilePath != merged[j].FilePath {
+			return fileOrder[merged[i].FilePath] < fileOrder[merged[j].FilePath]
 		}
+		return merged[i].Line < merged[j].Line
+	})
+	return merged
+}
 
-		// Add file to current batch
-		currentBatch.Files = append(currentBatch.Files, diff)
-		currentBatch.TotalChanges += fileChanges
+func reviewBatch(ctx context.Context, client *ai.Client, best string, batch types.FileBatch) ([]types.ReviewComment, error) {
+	for i, diff := range batch.Files {
+		broken := ""
+		if ctx := batch.Contexts[i]; ctx != nil && len(ctx.SyntaxErrors) > 0 {
+			broken = " ⚠️  does not parse"
+		}
+		if diff.Parts > 0 {
+			fmt.Printf("  📄 %s part %d/%d (+%d -%d)%s\n", diff.NewPath, diff.Part, diff.Parts, diff.Additions, diff.Deletions, broken)
+		} else {
+			fmt.Printf("  📄 %s (+%d -%d)%s\n", diff.NewPath, diff.Additions, diff.Deletions, broken)
+		}
 	}
 
-	// Don't forget the last batch
-	if len(currentBatch.Files) > 0 {
-		batches = append(batches, currentBatch)
+	// Send entire batch to AI in one request
+	resp, err := client.ReviewBatch(ctx, best, batch.Files, batch.Contexts)
+	if err != nil {
+		return nil, err
 	}
 
-	return batches
+	comments := anchorComments(resp.Comments, batch.Files)
+	comments = dropMovedComments(comments, batch.Files)
+	attachCode(comments, batch.Files)
+	return comments, nil
 }
 
-func reviewBatch(ctx context.Context, client *ai.Client, p *parser.Parser, best string, batch types.FileBatch, repoPath, targetBranch string, useTreeSitter bool) []types.ReviewComment {
-	// Enrich all files in the batch with context
-	var enrichedDiffs []types.FileDiff
-	var contexts []*types.CodeContext
-
-	for _, diff := range batch.Files {
-		fmt.Printf("  📄 %s (+%d -%d)", diff.NewPath, diff.Additions, diff.Deletions)
-
-		var context *types.CodeContext
-		var enrichedDiff types.FileDiff
-		var err error
-
-		if useTreeSitter && p != nil {
-			enrichedDiff, context, err = diffpkg.EnrichDiffWithContext(repoPath, diff, targetBranch, p)
-			if err != nil {
-				fmt.Printf("  ⚠️  Failed to enrich context: %v\n", err)
-				enrichedDiff = diff
-				context = nil
+// attachCode fills each comment with the content of the line it points at.
+func attachCode(comments []types.ReviewComment, files []types.FileDiff) {
+	byPath := filesByPath(files)
+	for i, c := range comments {
+		if f, ok := byPath[c.FilePath]; ok {
+			if line, ok := f.Line(c.Line); ok {
+				comments[i].Code = line.Content
 			}
-		} else {
-			enrichedDiff = diff
-			context = nil
 		}
-
-		enrichedDiffs = append(enrichedDiffs, enrichedDiff)
-		contexts = append(contexts, context)
-		fmt.Println()
 	}
+}
 
-	// Send entire batch to AI in one request
-	resp, err := client.ReviewBatch(ctx, best, enrichedDiffs, contexts)
-	if err != nil {
-		fmt.Printf("  ❌ Batch review failed: %v\n", err)
-		return nil
+// dropMovedComments removes comments on lines that only move existing code.
+func dropMovedComments(comments []types.ReviewComment, files []types.FileDiff) []types.ReviewComment {
+	byPath := filesByPath(files)
+
+	var kept []types.ReviewComment
+	for _, c := range comments {
+		if f, ok := byPath[c.FilePath]; ok && f.IsMoved(c.Line) {
+			continue
+		}
+		kept = append(kept, c)
 	}
+	return kept
+}
 
-	return resp.Comments
+// filesByPath indexes files by their new path. The parts of a file split for
+// review share its path, so their hunks and moves are gathered into one.
+func filesByPath(files []types.FileDiff) map[string]types.FileDiff {
+	byPath := make(map[string]types.FileDiff, len(files))
+	for _, f := range files {
+		if whole, ok := byPath[f.NewPath]; ok {
+			whole.Hunks = append(append([]types.Hunk(nil), whole.Hunks...), f.Hunks...)
+			whole.Moves = append(append([]types.MovedBlock(nil), whole.Moves...), f.Moves...)
+			f = whole
+		}
+		byPath[f.NewPath] = f
+	}
+	return byPath
 }
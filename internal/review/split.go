@@ -0,0 +1,148 @@
+package review
+
+import (
+	"strings"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// splitFile breaks a file diff with more than maxChanges changed lines into
+// review units of whole hunks. A single hunk that is still too large is cut
+// into smaller hunks, preferably at context lines. Each unit keeps the file
+// header so it reads as a complete diff of its own.
+func splitFile(diff types.FileDiff, maxChanges int) []types.FileDiff {
+	if diff.Hunks == nil {
+		diff.Hunks = diffpkg.ParseHunks(diff.Diff)
+	}
+	if diff.Additions+diff.Deletions <= maxChanges || len(diff.Hunks) == 0 {
+		return []types.FileDiff{diff}
+	}
+
+	var pieces []types.Hunk
+	for _, hunk := range diff.Hunks {
+		pieces = append(pieces, splitHunk(hunk, maxChanges)...)
+	}
+
+	var groups [][]types.Hunk
+	var current []types.Hunk
+	currentChanges := 0
+	for _, hunk := range pieces {
+		additions, deletions := hunk.Count()
+		if len(current) > 0 && currentChanges+additions+deletions > maxChanges {
+			groups = append(groups, current)
+			current, currentChanges = nil, 0
+		}
+		current = append(current, hunk)
+		currentChanges += additions + deletions
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	if len(groups) == 1 {
+		return []types.FileDiff{diff}
+	}
+
+	header := diffpkg.Header(diff.Diff)
+	parts := make([]types.FileDiff, 0, len(groups))
+	for i, hunks := range groups {
+		part := diff
+		part.Hunks = hunks
+		part.Part = i + 1
+		part.Parts = len(groups)
+		part.Additions, part.Deletions = 0, 0
+
+		var b strings.Builder
+		b.WriteString(header)
+		for _, hunk := range hunks {
+			b.WriteString(hunk.String())
+			additions, deletions := hunk.Count()
+			part.Additions += additions
+			part.Deletions += deletions
+		}
+		part.Diff = b.String()
+		part.Moves = movesWithin(diff.Moves, hunks)
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// splitHunk cuts a hunk into consecutive hunks of at most maxChanges changed
+// lines. When possible a cut is made after a context line so that a block of
+// changes is not separated from the lines it replaces.
+func splitHunk(hunk types.Hunk, maxChanges int) []types.Hunk {
+	additions, deletions := hunk.Count()
+	if additions+deletions <= maxChanges {
+		return []types.Hunk{hunk}
+	}
+
+	var pieces []types.Hunk
+	oldPos, newPos := hunk.OldStart, hunk.NewStart
+	if hunk.OldLines == 0 {
+		oldPos++
+	}
+	if hunk.NewLines == 0 {
+		newPos++
+	}
+
+	lines := hunk.Lines
+	for len(lines) > 0 {
+		end, changes, lastContext := 0, 0, -1
+		for end < len(lines) {
+			if lines[end].Kind != types.LineContext {
+				if changes == maxChanges {
+					break
+				}
+				changes++
+			} else if changes > 0 {
+				lastContext = end
+			}
+			end++
+		}
+		// Prefer ending on a context line in the second half of the window
+		if end < len(lines) && lastContext >= end/2 {
+			end = lastContext + 1
+		}
+
+		piece := types.Hunk{OldStart: oldPos, NewStart: newPos, Section: hunk.Section, Lines: lines[:end]}
+		for _, line := range piece.Lines {
+			if line.Kind != types.LineAdded {
+				piece.OldLines++
+			}
+			if line.Kind != types.LineRemoved {
+				piece.NewLines++
+			}
+		}
+		oldPos += piece.OldLines
+		newPos += piece.NewLines
+		// An empty side points at the line before, as in git's own headers
+		if piece.OldLines == 0 {
+			piece.OldStart--
+		}
+		if piece.NewLines == 0 {
+			piece.NewStart--
+		}
+
+		pieces = append(pieces, piece)
+		lines = lines[end:]
+	}
+	return pieces
+}
+
+// movesWithin keeps the moved blocks that overlap the added lines of hunks.
+func movesWithin(moves []types.MovedBlock, hunks []types.Hunk) []types.MovedBlock {
+	if len(moves) == 0 {
+		return nil
+	}
+	var kept []types.MovedBlock
+	for _, m := range moves {
+		for _, hunk := range hunks {
+			first, last := hunk.NewStart, hunk.NewStart+hunk.NewLines-1
+			if m.Start <= last && m.End >= first {
+				kept = append(kept, m)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,220 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	diffpkg "github.com/lawndlwd/golum/internal/diff"
+	"github.com/lawndlwd/golum/internal/imports"
+	"github.com/lawndlwd/golum/internal/parser"
+	"github.com/lawndlwd/golum/internal/types"
+)
+
+// Summarize compares the top-level declarations of the base and new versions
+// of every file, records the changes in the Symbols of diffs, and returns
+// them together with those of the deleted files. Both versions must be read
+// from the repository, so patches without one are not summarized. With a
+// resolver, removed exports that other files still import are reported as
+// issues, since those files no longer build.
+func Summarize(p *parser.Parser, resolver *imports.Resolver, diffs, deleted []types.FileDiff, repoPath string) ([]types.FileSummary, []types.ReviewComment) {
+	var summaries []types.FileSummary
+	var comments []types.ReviewComment
+	summarize := func(diff *types.FileDiff) {
+		if diff.Hunks == nil {
+			diff.Hunks = diffpkg.ParseHunks(diff.Diff)
+		}
+		symbols, base, basePath, ok := changedSymbols(p, *diff, repoPath)
+		if !ok || len(symbols) == 0 {
+			return
+		}
+		comments = append(comments, flagImportedRemovals(p, resolver, *diff, symbols, base, basePath)...)
+		diff.Symbols = symbols
+
+		summary := types.FileSummary{Path: diffPath(*diff), Status: diff.Status, Symbols: symbols}
+		if diff.Commit != nil {
+			summary.Commit = diff.Commit.SHA
+		}
+		summaries = append(summaries, summary)
+	}
+
+	for i := range diffs {
+		summarize(&diffs[i])
+	}
+	for i := range deleted {
+		summarize(&deleted[i])
+	}
+	attachCode(comments, diffs)
+	return summaries, comments
+}
+
+// changedSymbols reads both versions of a file and compares them. It also
+// returns the base version, which removed exports are looked up in.
+func changedSymbols(p *parser.Parser, diff types.FileDiff, repoPath string) ([]types.SymbolChange, string, string, bool) {
+	if repoPath == "" || diff.OldSource.Kind == types.ContentHunks || diff.NewSource.Kind == types.ContentHunks {
+		return nil, "", "", false
+	}
+
+	var base, basePath, current, currentPath string
+	if diff.Status != types.StatusAdded {
+		basePath = diff.OldPath
+		if basePath == "" {
+			basePath = diff.NewPath
+		}
+		var err error
+		if base, err = diffpkg.ReadContent(repoPath, diff.OldSource, basePath); err != nil {
+			return nil, "", "", false
+		}
+	}
+	if diff.Status != types.StatusDeleted {
+		currentPath = diff.NewPath
+		var err error
+		if current, err = diffpkg.ReadContent(repoPath, diff.NewSource, currentPath); err != nil {
+			return nil, "", "", false
+		}
+	}
+	return p.ChangedSymbols(base, basePath, current, currentPath), base, basePath, true
+}
+
+// flagImportedRemovals fills ImportedBy for the removed exports of a file and
+// returns a comment for each one still imported. A deleted file gets a
+// single comment listing every file importing it.
+func flagImportedRemovals(p *parser.Parser, resolver *imports.Resolver, diff types.FileDiff, symbols []types.SymbolChange, base, basePath string) []types.ReviewComment {
+	if resolver == nil || basePath == "" {
+		return nil
+	}
+
+	// Declarations may be exported under other names, e.g. "default"
+	exportedAs := make(map[string][]string)
+	for exported, def := range p.Exports(base, basePath).Definitions {
+		exportedAs[def.Name] = append(exportedAs[def.Name], exported)
+	}
+
+	var comments []types.ReviewComment
+	var fileImporters []string
+	deleted := diff.Status == types.StatusDeleted
+	if deleted {
+		fileImporters = resolver.Importers(diff.NewSource, basePath, "")
+		if len(fileImporters) > 0 {
+			comments = append(comments, types.ReviewComment{
+				FilePath: basePath,
+				Line:     1,
+				Severity: types.SeverityIssue,
+				Comment:  fmt.Sprintf("%s: This file is deleted, but %s", types.SeverityIssue, stillImported(fileImporters)),
+				Note:     "deleted file",
+			})
+		}
+	}
+
+	for i, s := range symbols {
+		if !s.RemovesExport() {
+			continue
+		}
+		names, ok := exportedAs[s.Name]
+		if !ok {
+			names = []string{s.Name}
+		}
+		seen := make(map[string]bool)
+		for _, name := range names {
+			for _, file := range resolver.Importers(diff.NewSource, basePath, name) {
+				if !seen[file] {
+					seen[file] = true
+					symbols[i].ImportedBy = append(symbols[i].ImportedBy, file)
+				}
+			}
+		}
+		if deleted {
+			if !ok && s.Kind == "component" && len(symbols[i].ImportedBy) == 0 {
+				// The component a single-file component declares is the file
+				symbols[i].ImportedBy = fileImporters
+			}
+			if len(fileImporters) > 0 {
+				// The comment on the file covers its declarations
+				continue
+			}
+		}
+		if len(symbols[i].ImportedBy) == 0 {
+			continue
+		}
+
+		if deleted {
+			// Go packages outlive their files, so only the declarations are
+			// still imported
+			comments = append(comments, types.ReviewComment{
+				FilePath: basePath,
+				Line:     1,
+				Severity: types.SeverityIssue,
+				Comment:  fmt.Sprintf("%s: `%s` is deleted with this file, but %s", types.SeverityIssue, s.Name, stillImported(symbols[i].ImportedBy)),
+				Note:     "deleted file",
+			})
+			continue
+		}
+		line := s.Line
+		if s.Change == types.SymbolRemoved {
+			line = removalLine(diff, s.Line)
+		} else if l, ok := diff.Line(line); !ok || l.Kind != types.LineAdded {
+			// The export was dropped elsewhere, e.g. from an export list
+			line = firstChangedLine(diff)
+		}
+		c := types.ReviewComment{
+			FilePath: diff.NewPath,
+			Line:     line,
+			Severity: types.SeverityIssue,
+			Comment:  fmt.Sprintf("%s: `%s` is no longer exported, but %s", types.SeverityIssue, s.Name, stillImported(symbols[i].ImportedBy)),
+			Note:     "removed export",
+		}
+		if c.Line == 0 {
+			c.Unanchored = true
+			c.Note = "removed export; the file has no added lines"
+		}
+		comments = append(comments, c)
+	}
+	return comments
+}
+
+func stillImported(files []string) string {
+	if len(files) == 1 {
+		return files[0] + " still imports it"
+	}
+	return strings.Join(files, ", ") + " still import it"
+}
+
+// removalLine returns the added line closest to where a line of the base
+// version was removed: the first one after it in its hunk, or the last one
+// before it. Without one, it is the first added line of the file, or 0.
+func removalLine(diff types.FileDiff, oldLine int) int {
+	for _, h := range diff.Hunks {
+		if oldLine < h.OldStart || oldLine >= h.OldStart+max(h.OldLines, 1) {
+			continue
+		}
+		line, passed := 0, false
+		for _, l := range h.Lines {
+			switch {
+			case l.Kind == types.LineRemoved && l.OldLine >= oldLine:
+				passed = true
+			case l.Kind == types.LineAdded:
+				line = l.NewLine
+				if passed {
+					return line
+				}
+			}
+		}
+		if line > 0 {
+			return line
+		}
+	}
+	return firstChangedLine(diff)
+}
+
+func firstChangedLine(diff types.FileDiff) int {
+	if changed := diff.ChangedLines(); len(changed) > 0 {
+		return changed[0]
+	}
+	return 0
+}
+
+func diffPath(diff types.FileDiff) string {
+	if diff.NewPath != "" {
+		return diff.NewPath
+	}
+	return diff.OldPath
+}
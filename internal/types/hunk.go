@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineKind tells whether a diff line is context, added or removed.
+type LineKind int
+
+const (
+	LineContext LineKind = iota
+	LineAdded
+	LineRemoved
+)
+
+// Prefix returns the unified diff marker of the kind.
+func (k LineKind) Prefix() string {
+	switch k {
+	case LineAdded:
+		return "+"
+	case LineRemoved:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// DiffLine is one line of a hunk. OldLine is 0 for added lines and NewLine is
+// 0 for removed lines.
+type DiffLine struct {
+	Kind      LineKind
+	Content   string
+	OldLine   int
+	NewLine   int
+	NoNewline bool // Followed by "\ No newline at end of file"
+}
+
+// Hunk is one "@@ -a,b +c,d @@ section" block of a unified diff.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string // Text after the closing @@, usually the enclosing function
+	Lines    []DiffLine
+}
+
+// Header renders the hunk header line.
+func (h Hunk) Header() string {
+	header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// String renders the hunk in unified diff format, with a trailing newline.
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header())
+	b.WriteString("\n")
+	for _, line := range h.Lines {
+		b.WriteString(line.Kind.Prefix())
+		b.WriteString(line.Content)
+		b.WriteString("\n")
+		if line.NoNewline {
+			b.WriteString("\\ No newline at end of file\n")
+		}
+	}
+	return b.String()
+}
+
+// ChangedLines returns the new-side numbers of the added lines.
+func (h Hunk) ChangedLines() []int {
+	var lines []int
+	for _, line := range h.Lines {
+		if line.Kind == LineAdded {
+			lines = append(lines, line.NewLine)
+		}
+	}
+	return lines
+}
+
+// Count returns the number of added and removed lines.
+func (h Hunk) Count() (additions, deletions int) {
+	for _, line := range h.Lines {
+		switch line.Kind {
+		case LineAdded:
+			additions++
+		case LineRemoved:
+			deletions++
+		}
+	}
+	return additions, deletions
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
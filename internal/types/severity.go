@@ -0,0 +1,114 @@
+package types
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Severity is the normalized severity of a review comment. Levels are ordered
+// so that a higher value is always more serious.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityQuestion
+	SeveritySuggestion
+	SeverityIssue
+	SeverityBlocking
+)
+
+var severityLabels = map[Severity]string{
+	SeverityInfo:       "info",
+	SeverityQuestion:   "question",
+	SeveritySuggestion: "suggestion(non-blocking)",
+	SeverityIssue:      "issue",
+	SeverityBlocking:   "suggestion(blocking)",
+}
+
+// String returns the label used in prompts and reports.
+func (s Severity) String() string {
+	if label, ok := severityLabels[s]; ok {
+		return label
+	}
+	return "info"
+}
+
+// ParseSeverity maps whatever the model returned ("suggestion(blocking)",
+// "Issue", "critical", ...) onto a Severity. Unknown values become SeverityInfo.
+func ParseSeverity(raw string) Severity {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case s == "":
+		return SeverityInfo
+	case strings.Contains(s, "non-blocking"), strings.Contains(s, "nonblocking"), strings.Contains(s, "non blocking"):
+		return SeveritySuggestion
+	case strings.Contains(s, "blocking"), strings.Contains(s, "critical"), strings.Contains(s, "blocker"):
+		return SeverityBlocking
+	case strings.Contains(s, "issue"), strings.Contains(s, "error"), strings.Contains(s, "warning"):
+		return SeverityIssue
+	case strings.Contains(s, "suggestion"), strings.Contains(s, "nit"):
+		return SeveritySuggestion
+	case strings.Contains(s, "question"):
+		return SeverityQuestion
+	default:
+		return SeverityInfo
+	}
+}
+
+// severityPrefix matches the label a comment starts with, e.g. "issue: ...".
+var severityPrefix = regexp.MustCompile(`(?i)^\s*(issue|suggestion\((?:non-)?blocking\)|suggestion|question|nit|blocking)\s*:`)
+
+// ParseSeverityPrefix returns the severity of a comment that starts with a
+// known label followed by ":". Other prose before a colon ("on the critical
+// path: ...") is not a severity.
+func ParseSeverityPrefix(comment string) (Severity, bool) {
+	m := severityPrefix.FindStringSubmatch(comment)
+	if m == nil {
+		return SeverityInfo, false
+	}
+	switch label := strings.ToLower(m[1]); label {
+	case "issue":
+		return SeverityIssue, true
+	case "question":
+		return SeverityQuestion, true
+	case "blocking", "suggestion(blocking)":
+		return SeverityBlocking, true
+	default:
+		return SeveritySuggestion, true
+	}
+}
+
+// ParseSeverityLevel parses a user supplied level name (as used by --fail-on).
+// Unlike ParseSeverity it rejects unknown names.
+func ParseSeverityLevel(raw string) (Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "info":
+		return SeverityInfo, true
+	case "question":
+		return SeverityQuestion, true
+	case "suggestion", "non-blocking":
+		return SeveritySuggestion, true
+	case "issue":
+		return SeverityIssue, true
+	case "blocking", "critical":
+		return SeverityBlocking, true
+	default:
+		return SeverityInfo, false
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		// Be lenient with models that return a number or null
+		*s = SeverityInfo
+		return nil
+	}
+	*s = ParseSeverity(raw)
+	return nil
+}
@@ -1,19 +1,171 @@
 package types
 
+import "fmt"
+
+// FileStatus describes how a file changed between the two sides of a diff.
+type FileStatus string
+
+const (
+	StatusAdded       FileStatus = "added"
+	StatusModified    FileStatus = "modified"
+	StatusDeleted     FileStatus = "deleted"
+	StatusRenamed     FileStatus = "renamed"
+	StatusCopied      FileStatus = "copied"
+	StatusTypeChanged FileStatus = "type-changed"
+	StatusBinary      FileStatus = "binary"
+)
+
 type FileDiff struct {
-	OldPath   string
-	NewPath   string
-	Diff      string
-	Additions int
-	Deletions int
-	Language  string
+	OldPath    string
+	NewPath    string
+	Status     FileStatus
+	Similarity int // Rename/copy similarity percentage reported by git
+	Diff       string
+	Hunks      []Hunk
+	Additions  int
+	Deletions  int
+	Language   string
+	Commit     *Commit // Set when reviewing one commit at a time
+	// Where the two versions of the file can be read from, which depends on
+	// how the diff was produced
+	OldSource ContentSource
+	NewSource ContentSource
+	// Moves lists added line ranges that only move existing code
+	Moves []MovedBlock
+	// WhitespaceOnly is set when every change of the file only touched whitespace
+	WhitespaceOnly bool
+	// Symbols lists the top-level declarations the change touched, when the
+	// change was summarized
+	Symbols []SymbolChange
+	// Part and Parts number the review units of a file too large to review
+	// at once; both are 0 when the file is reviewed whole
+	Part  int
+	Parts int
+}
+
+// MovedBlock is a range of added lines (new file numbering) whose content was
+// removed unchanged, apart from whitespace, from From.
+type MovedBlock struct {
+	Start int
+	End   int
+	From  string
+}
+
+// ChangedLines returns the new-side numbers of every added line.
+func (d FileDiff) ChangedLines() []int {
+	var lines []int
+	for _, h := range d.Hunks {
+		lines = append(lines, h.ChangedLines()...)
+	}
+	return lines
+}
+
+// Line returns the diff line with the given new-side number, if it is part of
+// a hunk.
+func (d FileDiff) Line(newLine int) (DiffLine, bool) {
+	for _, h := range d.Hunks {
+		if newLine < h.NewStart || newLine >= h.NewStart+max(h.NewLines, 1) {
+			continue
+		}
+		for _, line := range h.Lines {
+			if line.Kind != LineRemoved && line.NewLine == newLine {
+				return line, true
+			}
+		}
+	}
+	return DiffLine{}, false
+}
+
+// IsMoved reports whether the given new-side line belongs to a moved block.
+func (d FileDiff) IsMoved(line int) bool {
+	for _, m := range d.Moves {
+		if line >= m.Start && line <= m.End {
+			return true
+		}
+	}
+	return false
+}
+
+// MovedLines returns how many added lines are moved code.
+func (d FileDiff) MovedLines() int {
+	count := 0
+	for _, m := range d.Moves {
+		count += m.End - m.Start + 1
+	}
+	return count
+}
+
+// ContentKind tells where a version of a file lives.
+type ContentKind int
+
+const (
+	ContentWorkTree ContentKind = iota // The file on disk
+	ContentIndex                       // The staged version
+	ContentRevision                    // The version at ContentSource.Rev
+	ContentHunks                       // Only the lines of the diff itself (no repository)
+)
+
+// ContentSource identifies one side of a diff.
+type ContentSource struct {
+	Kind ContentKind
+	Rev  string
+}
+
+func WorkTree() ContentSource           { return ContentSource{Kind: ContentWorkTree} }
+func Index() ContentSource              { return ContentSource{Kind: ContentIndex} }
+func Revision(rev string) ContentSource { return ContentSource{Kind: ContentRevision, Rev: rev} }
+func HunksOnly() ContentSource          { return ContentSource{Kind: ContentHunks} }
+
+func (s ContentSource) String() string {
+	switch s.Kind {
+	case ContentIndex:
+		return "index"
+	case ContentRevision:
+		return s.Rev
+	case ContentHunks:
+		return "diff hunks"
+	default:
+		return "working tree"
+	}
+}
+
+// Commit is a single commit of a reviewed range.
+type Commit struct {
+	SHA     string
+	Subject string
+	Message string // Full commit message, subject included
+}
+
+// ShortSHA returns the abbreviated commit hash used in reports.
+func (c Commit) ShortSHA() string {
+	if len(c.SHA) > 10 {
+		return c.SHA[:10]
+	}
+	return c.SHA
 }
 
 type ReviewComment struct {
-	FilePath string `json:"filePath"`
-	Line     int    `json:"line"`
-	Comment  string `json:"comment"`
-	Severity string `json:"severity"`
+	FilePath string   `json:"filePath"`
+	Line     int      `json:"line"`
+	Comment  string   `json:"comment"`
+	Severity Severity `json:"severity"`
+	Commit   string   `json:"commit,omitempty"`
+	Code     string   `json:"code,omitempty"` // Content of the commented line
+	// Note explains how the location was corrected, or why it could not be
+	// anchored to a changed line (Unanchored)
+	Note       string `json:"note,omitempty"`
+	Unanchored bool   `json:"unanchored,omitempty"`
+}
+
+// ASTRule is a rule checked locally with a tree-sitter query instead of by
+// the model. Its matches on changed lines become review comments.
+type ASTRule struct {
+	Name      string
+	Message   string // May reference captures as {{name}}
+	Severity  Severity
+	Languages []string // Languages the query is written for; empty for TypeScript and JavaScript
+	Query     string
+	Source    string // Rules file the rule comes from
 }
 
 type AIReviewResponse struct {
@@ -22,11 +174,134 @@ type AIReviewResponse struct {
 }
 
 type CodeContext struct {
-	ChangedLines []int            // Line numbers that were changed
-	Surrounding  map[int]string   // Line number -> surrounding context (5 lines before/after)
+	ChangedLines []int // Line numbers that were changed
+	// Regions of the new file around the changed lines, sorted and
+	// non-overlapping
+	Regions []ContextRegion
+	// Removed holds the regions of the base version around removed blocks
+	Removed []ContextRegion
+	// Definitions are top-level declarations of the file used by the changed
+	// lines but not already shown in Regions
+	Definitions []Definition
+	// Imported are the exports of other modules used by the changed lines
+	Imported []Definition
+	// SyntaxErrors are the parse errors of the new file that overlap the
+	// changed lines
+	SyntaxErrors []SyntaxError
+}
+
+// SyntaxError is a parse error of a file. Positions are 1-based and
+// inclusive; a missing token has an empty range.
+type SyntaxError struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	Missing   string // Token the grammar expected, for a missing token
+	Text      string // Text the grammar could not parse, otherwise
+}
+
+// String describes the error and where it is, e.g. "missing `)` at 12:30".
+func (e SyntaxError) String() string {
+	what := fmt.Sprintf("unexpected `%s`", e.Text)
+	if e.Missing != "" {
+		what = fmt.Sprintf("missing `%s`", e.Missing)
+	}
+	if e.EndLine == e.Line && e.EndColumn <= e.Column {
+		return fmt.Sprintf("%s at %d:%d", what, e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s at %d:%d-%d:%d", what, e.Line, e.Column, e.EndLine, e.EndColumn)
+}
+
+// Definition is a top-level declaration referenced by the changed lines.
+type Definition struct {
+	Path    string // Module the definition is imported from; empty for the reviewed file
+	Name    string
+	Kind    string // function, component, hook, class, interface, type, enum or variable
+	Start   int    // 1-based, inclusive
+	End     int
+	Partial bool   // Text only holds the signature or the first lines
+	Text    string // Numbered lines of the declaration
+}
+
+// SymbolChangeKind tells how a change touched a top-level declaration.
+type SymbolChangeKind string
+
+const (
+	SymbolAdded     SymbolChangeKind = "added"
+	SymbolRemoved   SymbolChangeKind = "removed"
+	SymbolSignature SymbolChangeKind = "signature changed" // Its parameters, type or visibility changed
+	SymbolModified  SymbolChangeKind = "modified"          // Only its body or value changed
+)
+
+// SymbolChange is a top-level declaration that a change added, removed or
+// modified.
+type SymbolChange struct {
+	Change      SymbolChangeKind
+	Kind        string // function, component, hook, class, type, ...
+	Name        string
+	Exported    bool // Other files can use it in the new version
+	WasExported bool // ... or could in the base version
+	Line        int  // In the new version, or the base for removed declarations
+	// Before and After are the signatures of a signature change, with
+	// whitespace collapsed
+	Before string
+	After  string
+	// ImportedBy lists the files that still import a removed export
+	ImportedBy []string
+}
+
+// RemovesExport reports whether other files could use the declaration before
+// the change but no longer can.
+func (c SymbolChange) RemovesExport() bool {
+	return c.WasExported && (c.Change == SymbolRemoved || !c.Exported)
+}
+
+// String describes the change, e.g. "removed exported function `fetchUser`".
+func (c SymbolChange) String() string {
+	what := fmt.Sprintf("%s `%s`", c.Kind, c.Name)
+	exported := func(yes bool) string {
+		if yes {
+			return "exported " + what
+		}
+		return what
+	}
+	switch {
+	case c.Change == SymbolRemoved:
+		return "removed " + exported(c.WasExported)
+	case c.Change == SymbolAdded:
+		return "added " + exported(c.Exported)
+	case c.RemovesExport():
+		return what + " is no longer exported"
+	case c.Exported && !c.WasExported:
+		return what + " is now exported"
+	case c.Change == SymbolSignature:
+		return "changed the signature of " + exported(c.Exported)
+	default:
+		return "modified " + exported(c.Exported)
+	}
+}
+
+// FileSummary lists the declarations a change touched in one file.
+type FileSummary struct {
+	Path    string
+	Status  FileStatus
+	Commit  string // SHA of the commit, when reviewing commits one at a time
+	Symbols []SymbolChange
+}
+
+// ContextRegion is a contiguous range of lines shown as context, each line
+// numbered and changed lines marked with ">>>".
+type ContextRegion struct {
+	Start  int // 1-based, inclusive
+	End    int
+	Scopes []string // Enclosing scopes covered, e.g. "component UserCard (lines 7-19)"
+	Text   string
 }
 
 type FileBatch struct {
 	Files        []FileDiff
+	Contexts     []*CodeContext // Parallel to Files; nil entries have no context
 	TotalChanges int
+	Tokens       int // Estimated prompt size
 }